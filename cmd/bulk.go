@@ -1,23 +1,31 @@
 package cmd
 
 import (
-	"bufio"
 	"context"
+	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/fatih/color"
-	"github.com/schollz/progressbar/v3"
-	"github.com/spf13/cobra"
+	"github.com/nephila016/emailchecker/internal/classifier"
 	"github.com/nephila016/emailchecker/internal/debug"
+	"github.com/nephila016/emailchecker/internal/extract"
+	"github.com/nephila016/emailchecker/internal/input"
 	"github.com/nephila016/emailchecker/internal/output"
 	"github.com/nephila016/emailchecker/internal/verifier"
 	"github.com/nephila016/emailchecker/internal/worker"
+	"github.com/schollz/progressbar/v3"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 var (
@@ -38,6 +46,33 @@ var (
 	bulkResume         bool
 	bulkProxy          string
 	bulkCatchAll       bool
+	bulkSyntax         string
+	bulkNoProvider     bool
+	bulkTypoMX         bool
+	bulkRoleLevel      string
+	bulkScoreProfile   string
+	bulkProbes         int
+	bulkDataProbe      bool
+	bulkCatchAllTTL    time.Duration
+	bulkDedupe         string
+	bulkEmailColumn    string
+	bulkInputFormat    string
+	bulkOutputFormat   string
+	bulkDedupeReport   string
+	bulkExtract        string
+	bulkSplitDir       string
+	bulkSplitFormat    string
+	bulkSplitBuckets   []string
+	bulkSplitDefaults  bool
+	bulkSheet          string
+	bulkFields         []string
+	bulkDelimiter      string
+	bulkQuote          string
+	bulkTimeFormat     string
+	bulkReport         string
+
+	// bulkConsole receives settings, progress and summary output
+	bulkConsole io.Writer = os.Stdout
 )
 
 var bulkCmd = &cobra.Command{
@@ -53,25 +88,48 @@ Features:
   - Incremental saving
   - Graceful shutdown on Ctrl+C
   - Automatic MX fallback (tries secondary MX if primary is down)
-  - Duplicate email removal
+  - Duplicate email removal (exact or canonical)
+  - CSV/TSV/JSONL/XLSX input with original columns carried through to the output
+  - Address extraction from text, HTML, mbox and EML documents (--extract)
+  - Per-status and custom filtered output files (--split-dir)
+  - SQLite output that updates results across reruns (-o results.db)
+  - Self-contained HTML report to share (-o report.html or --report)
 
 Examples:
   emailchecker bulk -f emails.txt -o results.csv
   emailchecker bulk -f emails.txt -i mail.example.com -p 25 -w 5
   emailchecker bulk -f emails.txt --health-email info@example.com
-  emailchecker bulk -f emails.txt -d 3 --jitter 2 -o results.json`,
+  emailchecker bulk -f emails.txt --delay 3 --jitter 2 -o results.json
+  emailchecker bulk -f contacts.csv --email-column "Work Email" -o results.csv
+  emailchecker bulk -f leads.xlsx --sheet Contacts -o results.xlsx
+  emailchecker bulk -f emails.txt -o results.db
+  emailchecker bulk -f emails.txt -o results.csv --report report.html
+  cat emails.txt | emailchecker bulk -f - -o - | jq .status
+  emailchecker bulk -f export.mbox --extract -o results.csv
+  emailchecker bulk -f emails.txt --split-dir out/ --split-format csv,txt --split-bucket 'clean=status=valid && !role'`,
 	RunE: runBulk,
 }
 
 func init() {
 	rootCmd.AddCommand(bulkCmd)
 
-	bulkCmd.Flags().StringVarP(&bulkFile, "file", "f", "", "Input file with emails (required)")
+	bulkCmd.Flags().StringVarP(&bulkFile, "file", "f", "", "Input file with emails: txt, csv, tsv, jsonl or xlsx; - reads stdin (required)")
+	bulkCmd.Flags().StringVar(&bulkEmailColumn, "email-column", "", "Email column of CSV/TSV/JSONL/XLSX input, by header name or 1-based index (default: detected)")
+	bulkCmd.Flags().StringVar(&bulkInputFormat, "input-format", "", "Input format: txt, csv, tsv, jsonl or xlsx (default: from the file extension)")
+	bulkCmd.Flags().StringVar(&bulkSheet, "sheet", "", "Worksheet of XLSX input, by name or 1-based index (default: the first sheet)")
 	bulkCmd.Flags().StringVarP(&bulkIP, "ip", "i", "", "Custom SMTP server IP/hostname")
 	bulkCmd.Flags().IntVarP(&bulkPort, "port", "p", 25, "SMTP port")
-	bulkCmd.Flags().StringVarP(&bulkOutput, "output", "o", "results.csv", "Output file")
+	bulkCmd.Flags().StringVarP(&bulkOutput, "output", "o", "results.csv", "Output file; - streams to stdout")
+	bulkCmd.Flags().StringVar(&bulkExtract, "extract", "", "Extract addresses from a text, HTML, mbox or EML document instead of reading a list (auto, text, html, eml, mbox)")
+	bulkCmd.Flags().Lookup("extract").NoOptDefVal = "auto"
+	bulkCmd.Flags().StringVar(&bulkOutputFormat, "output-format", "", "Output format: csv, json, jsonl, txt, xlsx, sqlite or html (default: from the file extension, jsonl for stdout)")
+	bulkCmd.Flags().StringVar(&bulkReport, "report", "", "Also write an HTML report of the run to this file")
+	bulkCmd.Flags().StringSliceVar(&bulkFields, "fields", nil, "Fields to write, in order, as name or name:Header; dotted paths reach nested data (default from config, else the format's defaults)")
+	bulkCmd.Flags().StringVar(&bulkDelimiter, "delimiter", "", "CSV/TXT field delimiter: a character, tab, comma, semicolon, pipe or space (default , for CSV, tab for TXT)")
+	bulkCmd.Flags().StringVar(&bulkQuote, "quote", "", "CSV quoting: minimal or all (default minimal)")
+	bulkCmd.Flags().StringVar(&bulkTimeFormat, "time-format", "", "Timestamp format: rfc3339, rfc3339nano, unix, unix_ms, local or a Go layout (default rfc3339)")
 	bulkCmd.Flags().IntVarP(&bulkWorkers, "workers", "w", 3, "Number of concurrent workers")
-	bulkCmd.Flags().Float64VarP(&bulkDelay, "delay", "d", 2.0, "Delay between checks (seconds)")
+	bulkCmd.Flags().Float64Var(&bulkDelay, "delay", 2.0, "Delay between checks (seconds)")
 	bulkCmd.Flags().Float64Var(&bulkJitter, "jitter", 1.0, "Random jitter added to delay (seconds)")
 	bulkCmd.Flags().IntVarP(&bulkTimeout, "timeout", "t", 15, "Connection timeout (seconds)")
 	bulkCmd.Flags().StringVar(&bulkFromAddress, "from", "test@gmail.com", "MAIL FROM address")
@@ -83,6 +141,22 @@ func init() {
 	bulkCmd.Flags().BoolVar(&bulkResume, "resume", false, "Resume from last position (not yet implemented)")
 	bulkCmd.Flags().StringVar(&bulkProxy, "proxy", "", "SOCKS5 proxy socks5://[user:pass@]host:port (not yet implemented)")
 	bulkCmd.Flags().BoolVar(&bulkCatchAll, "catch-all", false, "Check for catch-all domains")
+	bulkCmd.Flags().IntVar(&bulkProbes, "catch-all-probes", verifier.DefaultCatchAllProbes, "Random recipients to probe per domain for catch-all")
+	bulkCmd.Flags().DurationVar(&bulkCatchAllTTL, "catch-all-ttl", verifier.DefaultCatchAllCacheTTL, "How long a domain's catch-all verdict is reused (negative = probe every address)")
+	bulkCmd.Flags().BoolVar(&bulkDataProbe, "catch-all-data", false, "Issue DATA for an accepted probe to detect accept-then-bounce servers (no message is sent)")
+	bulkCmd.Flags().BoolVar(&bulkNoProvider, "skip-provider-rules", false, "Skip provider-specific local part rules (Gmail, Outlook, Yahoo)")
+	bulkCmd.Flags().BoolVar(&bulkTypoMX, "typo-mx", false, "Only suggest typo corrections whose domain has MX records")
+	bulkCmd.Flags().StringVar(&bulkSyntax, "syntax", "practical", "Syntax strictness: rfc, practical or provider")
+	bulkCmd.Flags().StringVar(&bulkScoreProfile, "score-profile", "", "Scoring profile for confidence_score (see 'score profiles')")
+	bulkCmd.Flags().StringVar(&bulkRoleLevel, "role-confidence", "medium", "Minimum confidence to report a role account: low, medium or high")
+
+	bulkCmd.Flags().StringVar(&bulkSplitDir, "split-dir", "", "Also write results split into per-status, disposable and role files in this directory")
+	bulkCmd.Flags().StringVar(&bulkSplitFormat, "split-format", "", "Comma-separated formats for split files: csv, json, jsonl, txt, xlsx, sqlite, html (default from config, else csv)")
+	bulkCmd.Flags().StringArrayVar(&bulkSplitBuckets, "split-bucket", nil, "Custom split bucket as name=filter, e.g. 'clean=status=valid && !role' (repeatable)")
+	bulkCmd.Flags().BoolVar(&bulkSplitDefaults, "split-defaults", true, "Write the default status, disposable and role buckets when splitting")
+
+	bulkCmd.Flags().StringVar(&bulkDedupe, "dedupe", "exact", "Duplicate removal: exact (case-insensitive), canonical (plus tags, Gmail dots, aliases) or none")
+	bulkCmd.Flags().StringVar(&bulkDedupeReport, "dedupe-report", "", "Write the duplicate-to-kept address mapping to this CSV file")
 
 	bulkCmd.MarkFlagRequired("file")
 }
@@ -101,18 +175,115 @@ func runBulk(cmd *cobra.Command, args []string) error {
 			"Warning: --resume is not yet implemented and will be ignored")
 	}
 
-	// Load and deduplicate emails
-	emails, duplicates, err := loadEmails(bulkFile)
+	syntaxLevel, err := verifier.ParseSyntaxLevel(bulkSyntax)
+	if err != nil {
+		return err
+	}
+	roleConfidence, err := classifier.ParseRoleConfidence(bulkRoleLevel)
+	if err != nil {
+		return err
+	}
+	scoring, err := scoringModel(bulkScoreProfile)
 	if err != nil {
 		return err
 	}
 
-	if len(emails) == 0 {
-		return fmt.Errorf("no emails found in %s", bulkFile)
+	// Results go to stdout with -o -, so console output moves to stderr
+	bulkConsole = os.Stdout
+	if bulkOutput == "-" {
+		bulkConsole = os.Stderr
+	}
+
+	// Open the input: a file or an extracted document is loaded and
+	// deduplicated up front, stdin is read and deduplicated as addresses
+	// arrive
+	inputOpts := input.Options{EmailColumn: bulkEmailColumn, Sheet: bulkSheet}
+	if bulkInputFormat != "" {
+		if inputOpts.Format, err = input.ParseFormat(bulkInputFormat); err != nil {
+			return err
+		}
+	}
+	planner, err := newRowPlanner(bulkDedupe)
+	if err != nil {
+		return err
+	}
+
+	var (
+		src         *input.Reader
+		data        *input.Data
+		emails      []string
+		rows        []bulkRow
+		columns     []string
+		inputFormat input.Format
+		emailColumn string
+	)
+	switch {
+	case bulkExtract != "":
+		var kind extract.Kind
+		if kind, err = extract.ParseKind(bulkExtract); err == nil {
+			data, err = extractInput(bulkFile, kind)
+		}
+	case bulkFile == "-":
+		src, err = input.NewReader(os.Stdin, inputOpts)
+	default:
+		data, err = input.ReadFile(bulkFile, inputOpts)
+	}
+	if err != nil {
+		return err
 	}
 
+	if src != nil {
+		columns, inputFormat, emailColumn = src.Columns(), src.Format(), src.EmailColumn()
+	} else {
+		emails, rows = planner.planAll(data)
+		if len(rows) == 0 {
+			return fmt.Errorf("no emails found in %s", bulkFile)
+		}
+		columns, inputFormat, emailColumn = data.Columns, data.Format, data.EmailColumn
+	}
+
+	// Open output writer
+	format := output.DetectFormat(bulkOutput)
+	if bulkOutputFormat != "" {
+		if format, err = output.ParseFormat(bulkOutputFormat); err != nil {
+			return err
+		}
+	} else if bulkOutput == "-" {
+		format = output.FormatJSONL
+	}
+	outputOpts, err := outputOptions(bulkFields, bulkDelimiter, bulkQuote, bulkTimeFormat, columns)
+	if err != nil {
+		return err
+	}
+	outputOpts.EmailColumn = emailColumn
+	outputOpts.Settings = bulkRunSettings()
+	writer, err := output.NewWriterWithOptions(bulkOutput, format, outputOpts)
+	if err != nil {
+		return err
+	}
+	if bulkReport != "" {
+		report, err := output.NewWriterWithOptions(bulkReport, output.FormatHTML, outputOpts)
+		if err != nil {
+			writer.Close()
+			return err
+		}
+		writer = output.NewMultiWriter(writer, report)
+	}
+	if bulkSplitDir != "" {
+		split, err := newSplitWriter(outputOpts)
+		if err != nil {
+			writer.Close()
+			return err
+		}
+		writer = output.NewMultiWriter(writer, split)
+	}
+	defer writer.Close()
+
 	if !quiet {
-		printBulkSettings(len(emails), duplicates)
+		printBulkSettings(inputFormat, emailColumn, rows, len(emails), planner.duplicates)
+		if bulkDedupe == "canonical" {
+			printDedupeGroups(planner.groups)
+		}
 	}
 
 	// Initial health check
@@ -124,27 +295,28 @@ func runBulk(cmd *cobra.Command, args []string) error {
 
 	// Build verifier config
 	config := &verifier.Config{
-		CustomHost:        bulkIP,
-		Port:              bulkPort,
-		Timeout:           time.Duration(bulkTimeout) * time.Second,
-		FromAddress:       bulkFromAddress,
-		HELODomain:        bulkHELO,
-		SkipSMTP:          bulkSkipSMTP,
-		CheckCatchAll:     bulkCatchAll,
-		CheckDisposable:   true,
-		CheckRole:         true,
-		CheckFreeProvider: true,
+		CustomHost:         bulkIP,
+		Port:               bulkPort,
+		Timeout:            time.Duration(bulkTimeout) * time.Second,
+		FromAddress:        bulkFromAddress,
+		HELODomain:         bulkHELO,
+		SyntaxLevel:        syntaxLevel,
+		SkipSMTP:           bulkSkipSMTP,
+		CheckCatchAll:      bulkCatchAll,
+		CatchAllProbes:     bulkProbes,
+		CatchAllDataProbe:  bulkDataProbe,
+		CatchAllCacheTTL:   bulkCatchAllTTL,
+		CheckDisposable:    true,
+		CheckRole:          true,
+		CheckFreeProvider:  true,
+		CheckProviderRules: !bulkNoProvider,
+		ConfirmTypoMX:      bulkTypoMX,
+		RoleConfidence:     roleConfidence,
+		Scoring:            scoring,
+		Classifiers:        classifier.Registered(),
 	}
 	v := verifier.New(config)
 
-	// Open output writer
-	format := output.DetectFormat(bulkOutput)
-	writer, err := output.NewWriter(bulkOutput, format)
-	if err != nil {
-		return err
-	}
-	defer writer.Close()
-
 	// Build worker pool
 	poolConfig := &worker.PoolConfig{
 		Workers:        bulkWorkers,
@@ -159,6 +331,7 @@ func runBulk(cmd *cobra.Command, args []string) error {
 	// Statistics (protected by mutex)
 	var stats struct {
 		sync.Mutex
+		rows    int
 		valid   int
 		invalid int
 		unknown int
@@ -166,10 +339,15 @@ func runBulk(cmd *cobra.Command, args []string) error {
 		errors  int
 	}
 
-	// Progress bar
+	// Progress bar (a spinner when the total is not known)
 	var bar *progressbar.ProgressBar
 	if !quiet {
-		bar = progressbar.NewOptions(len(emails),
+		total := len(emails)
+		if src != nil {
+			total = -1
+		}
+		bar = progressbar.NewOptions(total,
+			progressbar.OptionSetWriter(bulkConsole),
 			progressbar.OptionSetDescription("Verifying"),
 			progressbar.OptionSetTheme(progressbar.Theme{
 				Saucer:        "=",
@@ -200,10 +378,21 @@ func runBulk(cmd *cobra.Command, args []string) error {
 		}
 	}()
 
-	// Per-result callback: update stats, write output, advance progress bar
-	pool.SetCallbacks(
+	// Results are written in input order: update stats and write output
+	seq := newRowSequencer(planner.keepsResults(inputFormat),
+		func(rec *input.Record) *verifier.Result {
+			if rec.Err != "" {
+				result := verifier.NewResult(rec.Email)
+				result.SetError(errors.New(rec.Err))
+				result.SubStatus = verifier.SubStatusMalformedInput
+				return result
+			}
+			// An empty email cell is reported as an invalid (empty) address
+			return v.Verify(rec.Email)
+		},
 		func(result *verifier.Result) {
 			stats.Lock()
+			stats.rows++
 			switch result.Status {
 			case verifier.StatusValid:
 				stats.valid++
@@ -219,15 +408,21 @@ func runBulk(cmd *cobra.Command, args []string) error {
 			stats.Unlock()
 
 			if err := writer.Write(result); err != nil {
-				log.Error("OUTPUT", "Failed to write result for %s: %v", result.Email, err)
+				log.Error("OUTPUT", "Failed to write result for row %d (%s): %v", result.Row, result.Email, err)
 			}
 			writer.Flush()
+		},
+	)
 
+	// Per-result callback: advance progress bar, hand over to the sequencer
+	pool.SetCallbacks(
+		func(result *verifier.Result) {
 			if bar != nil {
 				bar.Add(1) //nolint:errcheck
 			}
 
 			log.Detail("RESULT", "%s: %s (code: %d)", result.Email, result.Status, result.StatusCode)
+			seq.add(result)
 		},
 		nil,
 	)
@@ -235,71 +430,445 @@ func runBulk(cmd *cobra.Command, args []string) error {
 	// Start workers
 	pool.Start()
 
-	// Feed jobs to the pool in a separate goroutine so we can also drain results
+	// Feed jobs to the pool in a separate goroutine so we can also drain
+	// results. Each row is queued for output before its job is submitted.
+	var readErr error
 	go func() {
-		for i, email := range emails {
-			select {
-			case <-ctx.Done():
+		// All jobs submitted — signal workers to drain and exit
+		defer pool.Close()
+
+		if src == nil {
+			next := 0
+			for _, row := range rows {
+				if ctx.Err() != nil {
+					return
+				}
+				seq.push(row)
+				if row.job == next {
+					pool.Submit(emails[row.job], row.job)
+					next++
+				}
+			}
+			return
+		}
+
+		for ctx.Err() == nil {
+			rec, err := src.Next()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				readErr = err
 				return
-			default:
-				pool.Submit(email, i)
+			}
+			row, email, keep := planner.plan(rec, inputFormat)
+			if !keep {
+				continue
+			}
+			seq.push(row)
+			if email != "" {
+				pool.Submit(email, row.job)
 			}
 		}
-		// All jobs submitted — signal workers to drain and exit
-		pool.Close()
 	}()
 
 	// Drain result channel (actual processing happens in the callback)
 	for range pool.Results() {
 	}
+	seq.finish()
+
+	if bulkDedupeReport != "" && len(planner.groups) > 0 {
+		if err := writeDedupeReport(bulkDedupeReport, planner.groups); err != nil {
+			return err
+		}
+	}
 
 	// Finalize
 	if !quiet {
 		if bar != nil {
 			bar.Finish() //nolint:errcheck
 		}
-		printBulkSummary(&stats, len(emails), startTime)
+		printBulkSummary(&stats, stats.rows, startTime)
+		if bulkCatchAll {
+			cs := v.CatchAllStats()
+			fmt.Fprintf(bulkConsole, "Catch-all probes:  %d domains probed, %d reused\n", cs.Probes, cs.Hits)
+		}
 	}
 
-	fmt.Printf("\nResults saved to: %s\n", bulkOutput)
+	if readErr != nil {
+		return readErr
+	}
+	if bulkOutput != "-" {
+		fmt.Fprintf(bulkConsole, "\nResults saved to: %s\n", bulkOutput)
+	}
+	if bulkReport != "" {
+		fmt.Fprintf(bulkConsole, "Report saved to:  %s\n", bulkReport)
+	}
 	return nil
 }
 
-// loadEmails reads emails from filename, skipping blanks and comments.
-// It deduplicates (case-insensitive) and returns the unique list along with
-// the number of duplicates removed.
-func loadEmails(filename string) (emails []string, duplicates int, err error) {
-	f, err := os.Open(filename)
+// newSplitWriter builds the --split-dir writer from the flags and the
+// output.split config section:
+//
+//	output:
+//	  split:
+//	    format: csv,txt
+//	    buckets:
+//	      clean: status=valid && !role && !disposable
+func newSplitWriter(opts output.Options) (output.Writer, error) {
+	formatList := bulkSplitFormat
+	if formatList == "" {
+		formatList = viper.GetString("output.split.format")
+	}
+	if formatList == "" {
+		formatList = string(output.FormatCSV)
+	}
+	formats, err := output.ParseFormats(formatList)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to open file: %w", err)
+		return nil, err
 	}
-	defer f.Close()
 
-	seen := make(map[string]struct{})
+	// Later definitions replace earlier ones of the same name: config
+	// buckets override defaults, --split-bucket overrides both
+	var buckets []output.Bucket
+	add := func(b output.Bucket) {
+		for i := range buckets {
+			if buckets[i].Name == b.Name {
+				buckets[i] = b
+				return
+			}
+		}
+		buckets = append(buckets, b)
+	}
+	if bulkSplitDefaults {
+		for _, b := range output.DefaultBuckets() {
+			add(b)
+		}
+	}
 
-	scanner := bufio.NewScanner(f)
-	// Allow lines up to 1 MB (handles extremely long lines gracefully)
-	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	configured := viper.GetStringMapString("output.split.buckets")
+	names := make([]string, 0, len(configured))
+	for name := range configured {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b, err := output.NewBucket(name, configured[name])
+		if err != nil {
+			return nil, err
+		}
+		add(b)
+	}
 
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
+	for _, def := range bulkSplitBuckets {
+		b, err := output.ParseBucket(def)
+		if err != nil {
+			return nil, err
+		}
+		add(b)
+	}
+
+	return output.NewSplitWriter(bulkSplitDir, formats, opts, buckets)
+}
+
+// outputOptions builds writer options from output flags, falling back to
+// the output config section:
+//
+//	output:
+//	  fields: [email, status, "confidence_score:Score", input.company]
+//	  delimiter: ";"
+//	  quote: all
+//	  time_format: unix
+func outputOptions(fields []string, delimiter, quote, timeFormat string, columns []string) (output.Options, error) {
+	if fields == nil {
+		fields = viper.GetStringSlice("output.fields")
+	}
+	if delimiter == "" {
+		delimiter = viper.GetString("output.delimiter")
+	}
+	if quote == "" {
+		quote = viper.GetString("output.quote")
+	}
+	if timeFormat == "" {
+		timeFormat = viper.GetString("output.time_format")
+	}
+
+	delim, err := output.ParseDelimiter(delimiter)
+	if err != nil {
+		return output.Options{}, err
+	}
+	if len(fields) == 0 {
+		fields = nil
+	}
+	return output.Options{
+		Fields:       fields,
+		InputColumns: columns,
+		Delimiter:    delim,
+		Quote:        strings.ToLower(quote),
+		TimeFormat:   timeFormat,
+	}, nil
+}
+
+// bulkRunSettings describes the run for writers that record it
+func bulkRunSettings() []output.Setting {
+	server := "auto"
+	if bulkIP != "" {
+		server = fmt.Sprintf("%s:%d", bulkIP, bulkPort)
+	}
+	input := bulkFile
+	if input == "" || input == "-" {
+		input = "stdin"
+	}
+	return []output.Setting{
+		{Name: "input", Value: input},
+		{Name: "server", Value: server},
+		{Name: "workers", Value: strconv.Itoa(bulkWorkers)},
+		{Name: "delay", Value: fmt.Sprintf("%.1fs", bulkDelay)},
+		{Name: "jitter", Value: fmt.Sprintf("%.1fs", bulkJitter)},
+		{Name: "timeout", Value: fmt.Sprintf("%ds", bulkTimeout)},
+		{Name: "from", Value: bulkFromAddress},
+		{Name: "helo", Value: bulkHELO},
+		{Name: "skip_smtp", Value: strconv.FormatBool(bulkSkipSMTP)},
+		{Name: "catch_all", Value: strconv.FormatBool(bulkCatchAll)},
+		{Name: "syntax", Value: bulkSyntax},
+		{Name: "score_profile", Value: bulkScoreProfile},
+		{Name: "dedupe", Value: bulkDedupe},
+		{Name: "version", Value: version},
+	}
+}
+
+// dedupeGroup records the input lines that were collapsed onto one kept
+// address during deduplication.
+type dedupeGroup struct {
+	Key        string
+	Kept       string
+	Duplicates []string
+}
+
+// dedupeKey returns the key used to detect duplicates for the given mode
+func dedupeKey(email, mode string) string {
+	if mode == "canonical" {
+		return verifier.CanonicalizeEmail(email)
+	}
+	return strings.ToLower(email)
+}
+
+// bulkRow is one input row scheduled for output. job indexes the address
+// verified for the row, or is -1 when the row has nothing to verify.
+type bulkRow struct {
+	rec *input.Record
+	job int
+}
+
+// rowPlanner deduplicates input rows according to mode (exact, canonical or
+// none) and assigns each kept address a job index. It works row by row so
+// streamed input can be planned as it arrives.
+type rowPlanner struct {
+	mode string
+	seen map[string]*dedupeGroup
+	jobs map[string]int
+	next int
+	// groups of collapsed duplicates in first-seen order
+	groups []*dedupeGroup
+	// duplicates is the number of duplicate addresses found
+	duplicates int
+}
+
+func newRowPlanner(mode string) (*rowPlanner, error) {
+	switch mode {
+	case "exact", "canonical", "none":
+	default:
+		return nil, fmt.Errorf("unknown dedupe mode %q (use exact, canonical or none)", mode)
+	}
+	return &rowPlanner{
+		mode: mode,
+		seen: make(map[string]*dedupeGroup),
+		jobs: make(map[string]int),
+	}, nil
+}
+
+// plan schedules rec. email is set when rec needs a new verification, and
+// keep is false for a dropped row. Plain text duplicates are dropped; rows of
+// tabular input are all kept and share the verification of the first
+// occurrence, so no original data is lost.
+func (p *rowPlanner) plan(rec *input.Record, format input.Format) (row bulkRow, email string, keep bool) {
+	if rec.Err != "" || rec.Email == "" {
+		return bulkRow{rec: rec, job: -1}, "", true
+	}
+	if p.mode != "none" {
+		key := dedupeKey(rec.Email, p.mode)
+		if group, exists := p.seen[key]; exists {
+			if len(group.Duplicates) == 0 {
+				p.groups = append(p.groups, group)
+			}
+			group.Duplicates = append(group.Duplicates, rec.Email)
+			p.duplicates++
+			return bulkRow{rec: rec, job: p.jobs[key]}, "", format != input.FormatTXT
 		}
-		key := strings.ToLower(line)
-		if _, exists := seen[key]; exists {
-			duplicates++
+		p.seen[key] = &dedupeGroup{Key: key, Kept: rec.Email}
+		p.jobs[key] = p.next
+	}
+	row = bulkRow{rec: rec, job: p.next}
+	p.next++
+	return row, rec.Email, true
+}
+
+// planAll plans every record of data and returns the addresses to verify
+// and the rows to write in input order
+func (p *rowPlanner) planAll(data *input.Data) (emails []string, rows []bulkRow) {
+	for _, rec := range data.Records {
+		row, email, keep := p.plan(rec, data.Format)
+		if !keep {
 			continue
 		}
-		seen[key] = struct{}{}
-		emails = append(emails, line)
+		if email != "" {
+			emails = append(emails, email)
+		}
+		rows = append(rows, row)
 	}
+	return emails, rows
+}
 
-	if err := scanner.Err(); err != nil {
-		return nil, duplicates, fmt.Errorf("failed to read file: %w", err)
+// keepsResults reports whether later rows can reuse an earlier result, so
+// the sequencer must hold on to results after writing them
+func (p *rowPlanner) keepsResults(format input.Format) bool {
+	return p.mode != "none" && format != input.FormatTXT
+}
+
+// rowSequencer writes results in input row order even though workers
+// finish out of order. Rows without an address get their result when their
+// turn comes. Rows are pushed as they are read, and written rows are
+// released, so memory stays bounded by the rows in flight.
+type rowSequencer struct {
+	mu      sync.Mutex
+	pending []bulkRow
+	results map[int]*verifier.Result
+	keep    bool
+	// rowResult builds the result for a row that has nothing to verify
+	rowResult func(rec *input.Record) *verifier.Result
+	emit      func(*verifier.Result)
+}
+
+func newRowSequencer(keep bool, rowResult func(*input.Record) *verifier.Result, emit func(*verifier.Result)) *rowSequencer {
+	return &rowSequencer{
+		results:   make(map[int]*verifier.Result),
+		keep:      keep,
+		rowResult: rowResult,
+		emit:      emit,
 	}
+}
+
+// push queues a row for output. It must be called before the row's job is
+// submitted.
+func (s *rowSequencer) push(row bulkRow) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	return emails, duplicates, nil
+	s.pending = append(s.pending, row)
+	s.drain()
+}
+
+// add records the result of a job and writes every row that is now ready
+func (s *rowSequencer) add(result *verifier.Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.results[result.Index] = result
+	s.drain()
+}
+
+// finish writes the remaining rows after the pool has stopped, skipping
+// those whose job never completed (e.g. after Ctrl+C)
+func (s *rowSequencer) finish() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, row := range s.pending {
+		if r := s.rowFor(row); r != nil {
+			s.emit(r)
+		}
+	}
+	s.pending = nil
+}
+
+// drain writes queued rows until one is still waiting for its job
+func (s *rowSequencer) drain() {
+	for len(s.pending) > 0 {
+		row := s.pending[0]
+		r := s.rowFor(row)
+		if r == nil {
+			return
+		}
+		s.emit(r)
+		s.pending = s.pending[1:]
+		if !s.keep && row.job >= 0 {
+			delete(s.results, row.job)
+		}
+	}
+}
+
+// rowFor returns the output for row, or nil if its job has not finished
+func (s *rowSequencer) rowFor(row bulkRow) *verifier.Result {
+	var result *verifier.Result
+	if row.job < 0 {
+		result = s.rowResult(row.rec)
+	} else {
+		shared := s.results[row.job]
+		if shared == nil {
+			return nil
+		}
+		if shared.Row != 0 {
+			// A duplicate row reuses the verification of the first occurrence
+			c := *shared
+			c.Email = row.rec.Email
+			result = &c
+		} else {
+			result = shared
+		}
+	}
+	result.Row = row.rec.Row
+	result.Input = row.rec.Fields
+	return result
+}
+
+// writeDedupeReport writes one CSV row per removed duplicate, mapping it to
+// the address that was kept and verified in its place.
+func writeDedupeReport(filename string, groups []*dedupeGroup) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create dedupe report: %w", err)
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"key", "kept", "duplicate"}) //nolint:errcheck
+	for _, g := range groups {
+		for _, dup := range g.Duplicates {
+			w.Write([]string{g.Key, g.Kept, dup}) //nolint:errcheck
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// printDedupeGroups shows which addresses were merged by canonical dedup
+func printDedupeGroups(groups []*dedupeGroup) {
+	const maxShown = 10
+
+	if len(groups) == 0 {
+		return
+	}
+
+	cyan := color.New(color.FgCyan)
+	cyan.Fprintln(bulkConsole, "Canonical duplicates:")
+	for i, g := range groups {
+		if i == maxShown {
+			fmt.Fprintf(bulkConsole, "  ... and %d more (use --dedupe-report for the full mapping)\n", len(groups)-maxShown)
+			break
+		}
+		fmt.Fprintf(bulkConsole, "  %s <- %s\n", g.Kept, strings.Join(g.Duplicates, ", "))
+	}
+	fmt.Fprintln(bulkConsole)
 }
 
 func runInitialHealthCheck() bool {
@@ -310,8 +879,8 @@ func runInitialHealthCheck() bool {
 	yellow := color.New(color.FgYellow)
 
 	if !quiet {
-		yellow.Println("\n--- Initial Health Check ---")
-		fmt.Printf("Testing: %s\n", bulkHealthEmail)
+		yellow.Fprintln(bulkConsole, "\n--- Initial Health Check ---")
+		fmt.Fprintf(bulkConsole, "Testing: %s\n", bulkHealthEmail)
 	}
 
 	config := &verifier.Config{
@@ -327,55 +896,87 @@ func runInitialHealthCheck() bool {
 
 	if result.Status == verifier.StatusValid {
 		if !quiet {
-			green.Printf("Health check PASSED: %s is valid\n\n", bulkHealthEmail)
+			green.Fprintf(bulkConsole, "Health check PASSED: %s is valid\n\n", bulkHealthEmail)
 		}
 		log.Success("HEALTH", "Initial health check passed")
 		return true
 	}
 
 	if !quiet {
-		red.Printf("Health check FAILED: %s returned %s\n", bulkHealthEmail, result.Status)
+		red.Fprintf(bulkConsole, "Health check FAILED: %s returned %s\n", bulkHealthEmail, result.Status)
 		if result.Reason != "" {
-			fmt.Printf("Reason: %s\n", result.Reason)
+			fmt.Fprintf(bulkConsole, "Reason: %s\n", result.Reason)
 		}
 	}
 	log.Error("HEALTH", "Initial health check failed: %s", result.Status)
 	return false
 }
 
-func printBulkSettings(count, duplicates int) {
+// printBulkSettings shows the run settings. rows is nil for streamed input,
+// whose size is not known up front.
+func printBulkSettings(format input.Format, emailColumn string, rows []bulkRow, count, duplicates int) {
 	cyan := color.New(color.FgCyan)
 	white := color.New(color.FgWhite, color.Bold)
 	yellow := color.New(color.FgYellow)
 
-	fmt.Println()
-	cyan.Println("========================================")
-	white.Println("       Email Verification Tool")
-	cyan.Println("========================================")
-	fmt.Println()
-
-	fmt.Printf("Emails to verify:  %d\n", count)
+	fmt.Fprintln(bulkConsole)
+	cyan.Fprintln(bulkConsole, "========================================")
+	white.Fprintln(bulkConsole, "       Email Verification Tool")
+	cyan.Fprintln(bulkConsole, "========================================")
+	fmt.Fprintln(bulkConsole)
+
+	switch {
+	case rows == nil && format == input.FormatTXT:
+		fmt.Fprintf(bulkConsole, "Input:             stdin (streaming)\n")
+	case rows == nil:
+		fmt.Fprintf(bulkConsole, "Input:             stdin, %s (streaming, email column: %s)\n", format, emailColumn)
+	case format != input.FormatTXT:
+		fmt.Fprintf(bulkConsole, "Input:             %s, %d rows (email column: %s)\n", format, len(rows), emailColumn)
+	}
+	if rows != nil {
+		fmt.Fprintf(bulkConsole, "Emails to verify:  %d\n", count)
+	}
 	if duplicates > 0 {
-		yellow.Printf("Duplicates removed: %d\n", duplicates)
+		if format == input.FormatTXT {
+			yellow.Fprintf(bulkConsole, "Duplicates removed: %d\n", duplicates)
+		} else {
+			yellow.Fprintf(bulkConsole, "Duplicate rows:    %d (verified once)\n", duplicates)
+		}
+	}
+	unusable := 0
+	for _, row := range rows {
+		if row.job < 0 {
+			unusable++
+		}
+	}
+	if unusable > 0 {
+		yellow.Fprintf(bulkConsole, "Rows without email: %d\n", unusable)
 	}
 	if bulkIP != "" {
-		fmt.Printf("Server:            %s:%d\n", bulkIP, bulkPort)
+		fmt.Fprintf(bulkConsole, "Server:            %s:%d\n", bulkIP, bulkPort)
 	} else {
-		fmt.Printf("Server:            Auto (MX lookup with fallback)\n")
+		fmt.Fprintf(bulkConsole, "Server:            Auto (MX lookup with fallback)\n")
 	}
-	fmt.Printf("Workers:           %d\n", bulkWorkers)
-	fmt.Printf("Delay:             %.1fs (+%.1fs jitter)\n", bulkDelay, bulkJitter)
-	fmt.Printf("Timeout:           %ds\n", bulkTimeout)
+	fmt.Fprintf(bulkConsole, "Workers:           %d\n", bulkWorkers)
+	fmt.Fprintf(bulkConsole, "Delay:             %.1fs (+%.1fs jitter)\n", bulkDelay, bulkJitter)
+	fmt.Fprintf(bulkConsole, "Timeout:           %ds\n", bulkTimeout)
 	if bulkHealthEmail != "" {
-		fmt.Printf("Health check:      Every %d emails\n", bulkHealthInterval)
-		fmt.Printf("Health email:      %s\n", bulkHealthEmail)
+		fmt.Fprintf(bulkConsole, "Health check:      Every %d emails\n", bulkHealthInterval)
+		fmt.Fprintf(bulkConsole, "Health email:      %s\n", bulkHealthEmail)
+	}
+	fmt.Fprintf(bulkConsole, "Output:            %s\n", bulkOutput)
+	if bulkReport != "" {
+		fmt.Fprintf(bulkConsole, "Report:            %s\n", bulkReport)
+	}
+	if bulkSplitDir != "" {
+		fmt.Fprintf(bulkConsole, "Split into:        %s\n", bulkSplitDir)
 	}
-	fmt.Printf("Output:            %s\n", bulkOutput)
-	fmt.Println()
+	fmt.Fprintln(bulkConsole)
 }
 
 func printBulkSummary(stats *struct {
 	sync.Mutex
+	rows    int
 	valid   int
 	invalid int
 	unknown int
@@ -390,22 +991,22 @@ func printBulkSummary(stats *struct {
 	yellow := color.New(color.FgYellow)
 	cyan := color.New(color.FgCyan)
 
-	fmt.Println()
-	cyan.Println("========================================")
-	cyan.Println("              SUMMARY")
-	cyan.Println("========================================")
-	fmt.Println()
+	fmt.Fprintln(bulkConsole)
+	cyan.Fprintln(bulkConsole, "========================================")
+	cyan.Fprintln(bulkConsole, "              SUMMARY")
+	cyan.Fprintln(bulkConsole, "========================================")
+	fmt.Fprintln(bulkConsole)
 
 	stats.Lock()
 	defer stats.Unlock()
 
-	fmt.Printf("Total Verified:    %d\n", total)
-	green.Printf("Valid:             %d\n", stats.valid)
-	red.Printf("Invalid:           %d\n", stats.invalid)
-	yellow.Printf("Unknown:           %d\n", stats.unknown)
-	yellow.Printf("Risky:             %d\n", stats.risky)
-	red.Printf("Errors:            %d\n", stats.errors)
-	fmt.Println()
-	fmt.Printf("Duration:          %s\n", duration.Round(time.Second))
-	fmt.Printf("Rate:              %.2f emails/sec\n", rate)
+	fmt.Fprintf(bulkConsole, "Total Verified:    %d\n", total)
+	green.Fprintf(bulkConsole, "Valid:             %d\n", stats.valid)
+	red.Fprintf(bulkConsole, "Invalid:           %d\n", stats.invalid)
+	yellow.Fprintf(bulkConsole, "Unknown:           %d\n", stats.unknown)
+	yellow.Fprintf(bulkConsole, "Risky:             %d\n", stats.risky)
+	red.Fprintf(bulkConsole, "Errors:            %d\n", stats.errors)
+	fmt.Fprintln(bulkConsole)
+	fmt.Fprintf(bulkConsole, "Duration:          %s\n", duration.Round(time.Second))
+	fmt.Fprintf(bulkConsole, "Rate:              %.2f emails/sec\n", rate)
 }
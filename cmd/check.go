@@ -4,13 +4,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/fatih/color"
-	"github.com/spf13/cobra"
+	"github.com/nephila016/emailchecker/internal/classifier"
 	"github.com/nephila016/emailchecker/internal/debug"
 	"github.com/nephila016/emailchecker/internal/output"
 	"github.com/nephila016/emailchecker/internal/verifier"
+	"github.com/spf13/cobra"
 )
 
 var (
@@ -23,6 +25,17 @@ var (
 	checkOutput      string
 	checkJSON        bool
 	checkCatchAll    bool
+	checkSyntax      string
+	checkNoProvider  bool
+	checkTypoMX      bool
+	checkRoleLevel   string
+	checkScoreProf   string
+	checkProbes      int
+	checkDataProbe   bool
+	checkFields      []string
+	checkDelimiter   string
+	checkQuote       string
+	checkTimeFormat  string
 )
 
 var checkCmd = &cobra.Command{
@@ -57,7 +70,18 @@ func init() {
 	checkCmd.Flags().BoolVar(&checkSkipSMTP, "skip-smtp", false, "Skip SMTP verification")
 	checkCmd.Flags().StringVarP(&checkOutput, "output", "o", "", "Output file")
 	checkCmd.Flags().BoolVar(&checkJSON, "json", false, "Output as JSON to stdout")
+	checkCmd.Flags().StringSliceVar(&checkFields, "fields", nil, "Fields to write to the output file, as name or name:Header (default from config, else the format's defaults)")
+	checkCmd.Flags().StringVar(&checkDelimiter, "delimiter", "", "CSV/TXT field delimiter for the output file")
+	checkCmd.Flags().StringVar(&checkQuote, "quote", "", "CSV quoting: minimal or all")
+	checkCmd.Flags().StringVar(&checkTimeFormat, "time-format", "", "Timestamp format for the output file: rfc3339, rfc3339nano, unix, unix_ms, local or a Go layout")
 	checkCmd.Flags().BoolVar(&checkCatchAll, "catch-all", false, "Check for catch-all domain")
+	checkCmd.Flags().IntVar(&checkProbes, "catch-all-probes", verifier.DefaultCatchAllProbes, "Random recipients to probe for catch-all")
+	checkCmd.Flags().BoolVar(&checkDataProbe, "catch-all-data", false, "Issue DATA for an accepted probe to detect accept-then-bounce servers (no message is sent)")
+	checkCmd.Flags().BoolVar(&checkNoProvider, "skip-provider-rules", false, "Skip provider-specific local part rules (Gmail, Outlook, Yahoo)")
+	checkCmd.Flags().BoolVar(&checkTypoMX, "typo-mx", false, "Only suggest typo corrections whose domain has MX records")
+	checkCmd.Flags().StringVar(&checkSyntax, "syntax", "practical", "Syntax strictness: rfc, practical or provider")
+	checkCmd.Flags().StringVar(&checkScoreProf, "score-profile", "", "Scoring profile for confidence_score (see 'score profiles')")
+	checkCmd.Flags().StringVar(&checkRoleLevel, "role-confidence", "medium", "Minimum confidence to report a role account: low, medium or high")
 }
 
 func runCheck(cmd *cobra.Command, args []string) error {
@@ -66,18 +90,39 @@ func runCheck(cmd *cobra.Command, args []string) error {
 
 	log.Info("CHECK", "Verifying email: %s", email)
 
+	syntaxLevel, err := verifier.ParseSyntaxLevel(checkSyntax)
+	if err != nil {
+		return err
+	}
+	roleConfidence, err := classifier.ParseRoleConfidence(checkRoleLevel)
+	if err != nil {
+		return err
+	}
+	scoring, err := scoringModel(checkScoreProf)
+	if err != nil {
+		return err
+	}
+
 	// Create verifier config
 	config := &verifier.Config{
-		CustomHost:      checkIP,
-		Port:            checkPort,
-		Timeout:         time.Duration(checkTimeout) * time.Second,
-		FromAddress:     checkFromAddress,
-		HELODomain:      checkHELO,
-		SkipSMTP:        checkSkipSMTP,
-		CheckCatchAll:   checkCatchAll,
-		CheckDisposable: true,
-		CheckRole:       true,
-		CheckFreeProvider: true,
+		CustomHost:         checkIP,
+		Port:               checkPort,
+		Timeout:            time.Duration(checkTimeout) * time.Second,
+		FromAddress:        checkFromAddress,
+		HELODomain:         checkHELO,
+		SyntaxLevel:        syntaxLevel,
+		SkipSMTP:           checkSkipSMTP,
+		CheckCatchAll:      checkCatchAll,
+		CatchAllProbes:     checkProbes,
+		CatchAllDataProbe:  checkDataProbe,
+		CheckDisposable:    true,
+		CheckRole:          true,
+		CheckFreeProvider:  true,
+		CheckProviderRules: !checkNoProvider,
+		ConfirmTypoMX:      checkTypoMX,
+		RoleConfidence:     roleConfidence,
+		Scoring:            scoring,
+		Classifiers:        classifier.Registered(),
 	}
 
 	// Create verifier and run
@@ -103,8 +148,12 @@ func outputJSON(result *verifier.Result) error {
 }
 
 func outputToFile(result *verifier.Result, filename string) error {
+	opts, err := outputOptions(checkFields, checkDelimiter, checkQuote, checkTimeFormat, nil)
+	if err != nil {
+		return err
+	}
 	format := output.DetectFormat(filename)
-	writer, err := output.NewWriter(filename, format)
+	writer, err := output.NewWriterWithOptions(filename, format, opts)
 	if err != nil {
 		return err
 	}
@@ -148,6 +197,10 @@ func outputConsole(result *verifier.Result) error {
 		fmt.Printf("Reason: %s\n", result.Reason)
 	}
 
+	if result.DidYouMean != "" {
+		yellow.Printf("Did you mean: %s?\n", result.DidYouMean)
+	}
+
 	fmt.Println()
 	cyan.Println("Details:")
 
@@ -156,6 +209,9 @@ func outputConsole(result *verifier.Result) error {
 		fmt.Printf("  Syntax:       %s\n", green.Sprint("Valid"))
 	} else {
 		fmt.Printf("  Syntax:       %s\n", red.Sprint("Invalid"))
+		if result.SyntaxError != "" {
+			fmt.Printf("  Syntax Error: %s\n", result.SyntaxError)
+		}
 	}
 
 	// Domain
@@ -180,6 +236,15 @@ func outputConsole(result *verifier.Result) error {
 		fmt.Printf("  SMTP Check:   %s\n", yellow.Sprint("Not performed"))
 	}
 
+	// SMTPUTF8
+	if result.SMTPUTF8 {
+		if result.SubStatus == verifier.SubStatusUnverifiableUTF8 {
+			fmt.Printf("  SMTPUTF8:     %s\n", yellow.Sprint("Required (not supported by server)"))
+		} else {
+			fmt.Printf("  SMTPUTF8:     %s\n", green.Sprint("Required"))
+		}
+	}
+
 	// TLS
 	if result.TLSUsed {
 		fmt.Printf("  TLS:          %s\n", green.Sprint("Yes"))
@@ -190,18 +255,23 @@ func outputConsole(result *verifier.Result) error {
 
 	// Disposable
 	if result.Disposable {
-		fmt.Printf("  Disposable:   %s\n", red.Sprint("Yes"))
+		fmt.Printf("  Disposable:   %s (%s)\n", red.Sprint("Yes"), result.DisposableReason)
 	} else {
 		fmt.Printf("  Disposable:   %s\n", green.Sprint("No"))
 	}
 
 	// Role account
 	if result.RoleAccount {
-		fmt.Printf("  Role Account: %s\n", yellow.Sprint("Yes"))
+		fmt.Printf("  Role Account: %s (%s, %s confidence)\n", yellow.Sprint("Yes"), result.RoleCategory, result.RoleConfidence)
 	} else {
 		fmt.Printf("  Role Account: %s\n", green.Sprint("No"))
 	}
 
+	// Mailbox provider
+	if result.MailboxProvider != "" {
+		fmt.Printf("  Provider:     %s\n", result.MailboxProvider)
+	}
+
 	// Free provider
 	if result.FreeProvider {
 		fmt.Printf("  Free Provider: %s\n", yellow.Sprint("Yes"))
@@ -209,17 +279,34 @@ func outputConsole(result *verifier.Result) error {
 		fmt.Printf("  Free Provider: %s\n", green.Sprint("No"))
 	}
 
+	// Generated local part
+	if result.Generated {
+		fmt.Printf("  Generated:    %s (local part score %d/100)\n", yellow.Sprint("Likely"), result.LocalPartScore)
+	}
+
+	// Custom classifier tags
+	if len(result.Tags) > 0 {
+		fmt.Printf("  Tags:         %s\n", yellow.Sprint(strings.Join(result.Tags, ", ")))
+	}
+
 	// Catch-all
 	if result.CatchAllChecked {
-		if result.CatchAll {
-			fmt.Printf("  Catch-All:    %s\n", yellow.Sprint("Yes (risky)"))
-		} else {
-			fmt.Printf("  Catch-All:    %s\n", green.Sprint("No"))
+		probability := fmt.Sprintf("%.0f%% probability", result.CatchAllProbability*100)
+		switch {
+		case result.AcceptThenBounce:
+			fmt.Printf("  Catch-All:    %s (%s)\n", yellow.Sprint("Accept-then-bounce (risky)"), probability)
+		case result.CatchAll:
+			fmt.Printf("  Catch-All:    %s (%s)\n", yellow.Sprint("Yes (risky)"), probability)
+		default:
+			fmt.Printf("  Catch-All:    %s (%s)\n", green.Sprint("No"), probability)
 		}
 	}
 
 	fmt.Println()
-	fmt.Printf("Confidence Score: %d/100\n", result.ConfidenceScore)
+	fmt.Printf("Confidence Score: %d/100 (profile: %s)\n", result.ConfidenceScore, result.ScoreProfile)
+	for _, c := range result.ScoreBreakdown {
+		fmt.Printf("  %+4d  %s\n", c.Points, c.Factor)
+	}
 	fmt.Printf("Latency: %dms\n", result.LatencyMs)
 	fmt.Println()
 
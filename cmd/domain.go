@@ -7,9 +7,9 @@ import (
 	"time"
 
 	"github.com/fatih/color"
-	"github.com/spf13/cobra"
 	"github.com/nephila016/emailchecker/internal/debug"
 	"github.com/nephila016/emailchecker/internal/verifier"
+	"github.com/spf13/cobra"
 )
 
 var (
@@ -18,6 +18,12 @@ var (
 	domainCheckDMARC    bool
 	domainJSON          bool
 	domainTimeout       int
+	domainIP            string
+	domainPort          int
+	domainFromAddress   string
+	domainHELO          string
+	domainProbes        int
+	domainDataProbe     bool
 )
 
 var domainCmd = &cobra.Command{
@@ -43,6 +49,12 @@ func init() {
 	domainCmd.Flags().BoolVar(&domainCheckDMARC, "check-dmarc", false, "Check DMARC record")
 	domainCmd.Flags().BoolVar(&domainJSON, "json", false, "Output as JSON")
 	domainCmd.Flags().IntVarP(&domainTimeout, "timeout", "t", 15, "Timeout in seconds")
+	domainCmd.Flags().StringVarP(&domainIP, "ip", "i", "", "Custom SMTP server IP/hostname for the catch-all check")
+	domainCmd.Flags().IntVarP(&domainPort, "port", "p", 25, "SMTP port for the catch-all check")
+	domainCmd.Flags().StringVar(&domainFromAddress, "from", "test@gmail.com", "MAIL FROM address for the catch-all check")
+	domainCmd.Flags().StringVar(&domainHELO, "helo", "mail.verification-check.com", "EHLO domain for the catch-all check")
+	domainCmd.Flags().IntVar(&domainProbes, "catch-all-probes", verifier.DefaultCatchAllProbes, "Random recipients to probe for catch-all")
+	domainCmd.Flags().BoolVar(&domainDataProbe, "catch-all-data", false, "Issue DATA for an accepted probe to detect accept-then-bounce servers (no message is sent)")
 }
 
 func runDomain(cmd *cobra.Command, args []string) error {
@@ -52,7 +64,15 @@ func runDomain(cmd *cobra.Command, args []string) error {
 	log.Info("DOMAIN", "Checking domain: %s", domain)
 
 	config := &verifier.Config{
-		Timeout: time.Duration(domainTimeout) * time.Second,
+		CustomHost:        domainIP,
+		Port:              domainPort,
+		Timeout:           time.Duration(domainTimeout) * time.Second,
+		FromAddress:       domainFromAddress,
+		HELODomain:        domainHELO,
+		SkipTLSVerify:     true,
+		CatchAllProbes:    domainProbes,
+		CatchAllDataProbe: domainDataProbe,
+		MaxMXFallback:     3,
 	}
 	v := verifier.New(config)
 
@@ -72,8 +92,14 @@ func runDomain(cmd *cobra.Command, args []string) error {
 	}
 
 	// Check catch-all if requested
-	if domainCheckCatchAll && result.HasMX {
-		result.IsCatchAll = performCatchAllCheck(domain, result.MXRecords[0], config)
+	if domainCheckCatchAll && (result.HasMX || domainIP != "") {
+		verdict, err := v.CheckCatchAll(domain, result.MXRecords)
+		if err != nil {
+			log.Error("CATCHALL", "Failed to check catch-all: %v", err)
+		} else {
+			result.CatchAll = verdict
+			result.IsCatchAll = verdict.IsCatchAll() || verdict.AcceptThenBounce
+		}
 	}
 
 	if domainJSON {
@@ -83,37 +109,6 @@ func runDomain(cmd *cobra.Command, args []string) error {
 	return outputDomainConsole(result)
 }
 
-func performCatchAllCheck(domain, mxHost string, config *verifier.Config) bool {
-	log := debug.GetLogger()
-	log.Info("CATCHALL", "Testing catch-all for %s via %s", domain, mxHost)
-
-	// Generate random email
-	randomEmail := verifier.GenerateRandomEmail(domain)
-
-	smtpConfig := &verifier.SMTPConfig{
-		Host:        mxHost,
-		Port:        25,
-		Timeout:     config.Timeout,
-		FromAddress: "test@gmail.com",
-		HELODomain:  "mail.verification-check.com",
-	}
-
-	result, err := verifier.VerifyEmail(smtpConfig, randomEmail, false)
-	if err != nil {
-		log.Error("CATCHALL", "Failed to check catch-all: %v", err)
-		return false
-	}
-
-	isCatchAll := result.Status == verifier.StatusValid
-	if isCatchAll {
-		log.Info("CATCHALL", "Domain is catch-all (random email accepted)")
-	} else {
-		log.Info("CATCHALL", "Domain is NOT catch-all (random email rejected)")
-	}
-
-	return isCatchAll
-}
-
 func outputDomainJSON(result *verifier.DomainResult) error {
 	encoder := json.NewEncoder(os.Stdout)
 	encoder.SetIndent("", "  ")
@@ -145,7 +140,7 @@ func outputDomainConsole(result *verifier.DomainResult) error {
 	// Classification
 	cyan.Println("Classification:")
 	if result.IsDisposable {
-		fmt.Printf("  Disposable:    %s\n", red.Sprint("Yes"))
+		fmt.Printf("  Disposable:    %s (%s)\n", red.Sprint("Yes"), result.DisposableReason)
 	} else {
 		fmt.Printf("  Disposable:    %s\n", green.Sprint("No"))
 	}
@@ -156,11 +151,20 @@ func outputDomainConsole(result *verifier.DomainResult) error {
 		fmt.Printf("  Free Provider: %s\n", green.Sprint("No"))
 	}
 
+	if result.MailboxProvider != "" {
+		fmt.Printf("  Provider:      %s\n", result.MailboxProvider)
+	}
+
 	if domainCheckCatchAll {
-		if result.IsCatchAll {
-			fmt.Printf("  Catch-All:     %s\n", yellow.Sprint("Yes"))
-		} else {
-			fmt.Printf("  Catch-All:     %s\n", green.Sprint("No"))
+		switch v := result.CatchAll; {
+		case v == nil:
+			fmt.Printf("  Catch-All:     %s\n", yellow.Sprint("Unknown (check failed)"))
+		case v.AcceptThenBounce:
+			fmt.Printf("  Catch-All:     %s (%.0f%%, %s)\n", yellow.Sprint("Accept-then-bounce"), v.Probability*100, v.Reason)
+		case result.IsCatchAll:
+			fmt.Printf("  Catch-All:     %s (%.0f%%, %s)\n", yellow.Sprint("Yes"), v.Probability*100, v.Reason)
+		default:
+			fmt.Printf("  Catch-All:     %s (%.0f%%, %s)\n", green.Sprint("No"), v.Probability*100, v.Reason)
 		}
 	}
 	fmt.Println()
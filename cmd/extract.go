@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/nephila016/emailchecker/internal/extract"
+	"github.com/nephila016/emailchecker/internal/input"
+	"github.com/spf13/cobra"
+)
+
+var (
+	extractType   string
+	extractBody   bool
+	extractOutput string
+	extractFormat string
+)
+
+var extractCmd = &cobra.Command{
+	Use:   "extract <file|-> [file...]",
+	Short: "Extract email addresses from text, HTML, mbox and EML files",
+	Long: `Scan documents for email addresses and write the unique addresses found.
+
+Supported input:
+  - Plain text, including obfuscations such as "name [at] domain [dot] com"
+    and "name at domain dot com"
+  - HTML: mailto: links, visible text and entity-encoded addresses
+  - EML messages and mbox mailboxes: From, Sender, Reply-To, To, Cc and Bcc
+    headers (message bodies too with --body)
+
+The document type is detected from the file extension and content; force it
+with --type. Addresses are deduplicated case-insensitively in first-seen
+order. To verify what was found, use 'bulk --extract'.
+
+Examples:
+  emailchecker extract page.html
+  emailchecker extract export.mbox --body -o contacts.csv
+  curl -s https://example.com/contact | emailchecker extract - --type html`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runExtract,
+}
+
+func init() {
+	rootCmd.AddCommand(extractCmd)
+
+	extractCmd.Flags().StringVar(&extractType, "type", "auto", "Document type: auto, text, html, eml or mbox")
+	extractCmd.Flags().BoolVar(&extractBody, "body", false, "Also scan message bodies of EML and mbox files")
+	extractCmd.Flags().StringVarP(&extractOutput, "output", "o", "-", "Output file; - writes to stdout")
+	extractCmd.Flags().StringVar(&extractFormat, "format", "", "Output format: txt, csv, json or jsonl (default: from the output extension, else txt)")
+}
+
+func runExtract(cmd *cobra.Command, args []string) error {
+	kind, err := extract.ParseKind(extractType)
+	if err != nil {
+		return err
+	}
+
+	format := extractFormat
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(extractOutput)), ".")
+		if format == "ndjson" {
+			format = "jsonl"
+		}
+	}
+	switch format {
+	case "txt", "csv", "json", "jsonl":
+	case "":
+		format = "txt"
+	default:
+		if extractFormat != "" {
+			return fmt.Errorf("unknown output format %q (use txt, csv, json or jsonl)", extractFormat)
+		}
+		format = "txt"
+	}
+
+	ex := extract.New(extract.Options{Kind: kind, Body: extractBody})
+	for _, name := range args {
+		if err := extractFile(ex, name); err != nil {
+			return err
+		}
+	}
+	found := ex.Addresses()
+
+	out := io.Writer(os.Stdout)
+	if extractOutput != "-" {
+		f, err := os.Create(extractOutput)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := writeExtracted(out, format, found); err != nil {
+		return err
+	}
+
+	if !quiet {
+		color.New(color.FgCyan).Fprintf(os.Stderr, "Extracted %d unique addresses from %d file(s)\n", len(found), len(args))
+	}
+	return nil
+}
+
+// extractFile runs the extractor over a file, or stdin for "-"
+func extractFile(ex *extract.Extractor, name string) error {
+	if name == "-" {
+		return ex.Read(os.Stdin, "")
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+	return ex.Read(f, name)
+}
+
+// writeExtracted writes the addresses in the given format
+func writeExtracted(w io.Writer, format string, found []*extract.Address) error {
+	switch format {
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"email", "source", "count"}) //nolint:errcheck
+		for _, a := range found {
+			cw.Write([]string{a.Email, a.Source, strconv.Itoa(a.Count)}) //nolint:errcheck
+		}
+		cw.Flush()
+		return cw.Error()
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if found == nil {
+			found = []*extract.Address{}
+		}
+		return encoder.Encode(found)
+	case "jsonl":
+		encoder := json.NewEncoder(w)
+		for _, a := range found {
+			if err := encoder.Encode(a); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		for _, a := range found {
+			if _, err := fmt.Fprintln(w, a.Email); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// extractInput scans filename (or stdin for "-") with the extractor and
+// returns the addresses as bulk input rows, with where each was found
+// carried through as the "source" column
+func extractInput(filename string, kind extract.Kind) (*input.Data, error) {
+	ex := extract.New(extract.Options{Kind: kind})
+	if err := extractFile(ex, filename); err != nil {
+		return nil, err
+	}
+
+	data := &input.Data{Format: input.FormatTXT, Columns: []string{"source"}}
+	for i, a := range ex.Addresses() {
+		data.Records = append(data.Records, &input.Record{
+			Row:    i + 1,
+			Email:  a.Email,
+			Fields: map[string]string{"source": a.Source},
+		})
+	}
+	return data, nil
+}
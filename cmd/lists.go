@@ -0,0 +1,286 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/nephila016/emailchecker/internal/classifier"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var listsJSON bool
+
+var listsCmd = &cobra.Command{
+	Use:   "lists",
+	Short: "Manage classifier lists (disposable, free provider, role)",
+	Long: `Manage the lists used to classify addresses as disposable, free provider
+or role accounts.
+
+Extra lists are configured in .emailchecker.yaml as file paths or URLs
+(plain text, one entry per line, '#' starts a comment) and are merged with the
+built-in lists. URL sources are downloaded by 'lists update' and read from the
+local cache afterwards. Allow-list entries override every list, including the
+built-ins.
+
+Example configuration:
+  lists:
+    cache_dir: ""               # default: user cache dir
+    disposable:
+      sources:
+        - https://example.com/disposable_domains.txt
+        - /etc/emailchecker/disposable.txt
+      allow:
+        - mycompany-test.com
+    role:
+      allow:
+        - hi
+
+Examples:
+  emailchecker lists update
+  emailchecker lists show
+  emailchecker lists test mailinator.com
+  emailchecker lists test info@example.com`,
+}
+
+var listsUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Download, validate and cache configured list URLs",
+	Args:  cobra.NoArgs,
+	RunE:  runListsUpdate,
+}
+
+var listsShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show list sizes, sources and versions",
+	Args:  cobra.NoArgs,
+	RunE:  runListsShow,
+}
+
+var listsTestCmd = &cobra.Command{
+	Use:   "test <domain|email>",
+	Short: "Show which lists match a domain or address",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runListsTest,
+}
+
+func init() {
+	rootCmd.AddCommand(listsCmd)
+	listsCmd.AddCommand(listsUpdateCmd, listsShowCmd, listsTestCmd)
+
+	listsCmd.PersistentFlags().BoolVar(&listsJSON, "json", false, "Output as JSON")
+}
+
+// listConfigFromViper builds the classifier list configuration from the
+// "lists" section of the config file.
+func listConfigFromViper() *classifier.ListConfig {
+	cfg := &classifier.ListConfig{
+		CacheDir: viper.GetString("lists.cache_dir"),
+		Sources:  make(map[classifier.ListKind][]string),
+		Allow:    make(map[classifier.ListKind][]string),
+	}
+	if cfg.CacheDir == "" {
+		cfg.CacheDir = classifier.DefaultListCacheDir()
+	}
+	for _, kind := range classifier.ListKinds {
+		cfg.Sources[kind] = viper.GetStringSlice("lists." + string(kind) + ".sources")
+		cfg.Allow[kind] = viper.GetStringSlice("lists." + string(kind) + ".allow")
+	}
+	return cfg
+}
+
+// loadClassifierLists merges configured lists into the classifier. Failures
+// are reported as warnings; the built-in lists are always available.
+func loadClassifierLists(cmd *cobra.Command) {
+	if !viper.IsSet("lists") {
+		return
+	}
+	_, err := classifier.LoadLists(listConfigFromViper())
+	if err != nil && !quiet && cmd.Parent() != listsCmd {
+		color.New(color.FgYellow).Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// loadClassifierRules registers the rule-based classifiers defined under
+// "classifiers.rules" in the config file
+func loadClassifierRules() error {
+	if !viper.IsSet("classifiers.rules") {
+		return nil
+	}
+	var rules []classifier.Rule
+	if err := viper.UnmarshalKey("classifiers.rules", &rules); err != nil {
+		return fmt.Errorf("invalid classifiers configuration: %w", err)
+	}
+	return classifier.RegisterRules(rules)
+}
+
+func runListsUpdate(cmd *cobra.Command, args []string) error {
+	cfg := listConfigFromViper()
+	infos := classifier.UpdateLists(cfg)
+	if len(infos) == 0 {
+		fmt.Println("No list sources configured (see 'emailchecker lists --help').")
+		return nil
+	}
+
+	// Reload so this process (and 'lists show') reflects the new cache
+	classifier.LoadLists(cfg) //nolint:errcheck // failures are reported below
+
+	if listsJSON {
+		return printJSON(infos)
+	}
+
+	green := color.New(color.FgGreen)
+	red := color.New(color.FgRed)
+
+	failed := 0
+	for _, info := range infos {
+		if info.Error != "" {
+			failed++
+			red.Printf("  FAIL  %-10s %s\n", info.Kind, info.Source)
+			fmt.Printf("        %s\n", info.Error)
+			continue
+		}
+		green.Printf("  OK    %-10s %s\n", info.Kind, info.Source)
+		fmt.Printf("        %d entries (%d invalid skipped), version %s\n", info.Entries, info.Invalid, info.Version)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d list source(s) failed to update", failed, len(infos))
+	}
+	return nil
+}
+
+func runListsShow(cmd *cobra.Command, args []string) error {
+	sources := classifier.LoadedSources()
+
+	if listsJSON {
+		type kindSummary struct {
+			Kind    classifier.ListKind          `json:"kind"`
+			Builtin int                          `json:"builtin"`
+			Total   int                          `json:"total"`
+			Allow   int                          `json:"allow"`
+			Sources []*classifier.ListSourceInfo `json:"sources"`
+		}
+		var out []kindSummary
+		for _, kind := range classifier.ListKinds {
+			ks := kindSummary{
+				Kind:    kind,
+				Builtin: classifier.BuiltinCount(kind),
+				Total:   listTotal(kind),
+				Allow:   classifier.AllowCount(kind),
+				Sources: []*classifier.ListSourceInfo{},
+			}
+			for _, s := range sources {
+				if s.Kind == kind {
+					ks.Sources = append(ks.Sources, s)
+				}
+			}
+			out = append(out, ks)
+		}
+		return printJSON(out)
+	}
+
+	cyan := color.New(color.FgCyan)
+	red := color.New(color.FgRed)
+
+	for _, kind := range classifier.ListKinds {
+		fmt.Println()
+		name := string(kind)
+		cyan.Printf("%s list:\n", strings.ToUpper(name[:1])+name[1:])
+		fmt.Printf("  Built-in:   %d\n", classifier.BuiltinCount(kind))
+		fmt.Printf("  Total:      %d\n", listTotal(kind))
+		fmt.Printf("  Allow-list: %d\n", classifier.AllowCount(kind))
+		for _, s := range sources {
+			if s.Kind != kind {
+				continue
+			}
+			if s.Error != "" {
+				fmt.Printf("  - %s: %s\n", s.Source, red.Sprint(s.Error))
+				continue
+			}
+			fetched := "-"
+			if !s.FetchedAt.IsZero() {
+				fetched = s.FetchedAt.Format("2006-01-02 15:04")
+			}
+			fmt.Printf("  - %s: %d entries, version %s, updated %s\n", s.Source, s.Entries, s.Version, fetched)
+		}
+	}
+	fmt.Println()
+	return nil
+}
+
+func runListsTest(cmd *cobra.Command, args []string) error {
+	input := strings.ToLower(strings.TrimSpace(args[0]))
+	localPart, domain := "", input
+	if i := strings.LastIndex(input, "@"); i >= 0 {
+		localPart, domain = input[:i], input[i+1:]
+	}
+
+	type match struct {
+		List    classifier.ListKind `json:"list"`
+		Entry   string              `json:"entry"`
+		Matched bool                `json:"matched"`
+		Source  string              `json:"source,omitempty"`
+	}
+
+	var matches []match
+	if m := classifier.MatchDisposable(domain); m != nil {
+		matches = append(matches, match{List: classifier.ListDisposable, Entry: m.Entry, Matched: true, Source: m.Source})
+	} else {
+		matches = append(matches, match{List: classifier.ListDisposable, Entry: domain})
+	}
+	ok, src := classifier.Lookup(classifier.ListFree, domain)
+	matches = append(matches, match{List: classifier.ListFree, Entry: domain, Matched: ok, Source: src})
+	if localPart != "" {
+		role := classifier.DetectRole(localPart)
+		m := match{List: classifier.ListRole, Entry: localPart, Matched: classifier.IsRoleAccount(localPart)}
+		if role.Term != "" {
+			m.Entry = role.Term
+			m.Source = fmt.Sprintf("%s, %s, %s confidence", role.Source, role.Category, role.Confidence)
+		}
+		matches = append(matches, m)
+	}
+
+	if listsJSON {
+		return printJSON(matches)
+	}
+
+	green := color.New(color.FgGreen)
+	yellow := color.New(color.FgYellow)
+
+	fmt.Println()
+	for _, m := range matches {
+		if m.Matched {
+			fmt.Printf("  %-14s %s (%s, source: %s)\n", m.List+":", yellow.Sprint("Yes"), m.Entry, m.Source)
+		} else if m.Source != "" {
+			fmt.Printf("  %-14s %s (%s, below threshold: %s)\n", m.List+":", green.Sprint("No"), m.Entry, m.Source)
+		} else {
+			fmt.Printf("  %-14s %s (%s)\n", m.List+":", green.Sprint("No"), m.Entry)
+		}
+	}
+	fmt.Println()
+	return nil
+}
+
+// listTotal returns the merged size of a list
+func listTotal(kind classifier.ListKind) int {
+	switch kind {
+	case classifier.ListDisposable:
+		return classifier.GetDisposableCount()
+	case classifier.ListDisposableMX:
+		return classifier.GetDisposableMXCount()
+	case classifier.ListFree:
+		return classifier.GetFreeProviderCount()
+	default:
+		return classifier.GetRolePrefixCount()
+	}
+}
+
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,349 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/nephila016/emailchecker/internal/output"
+	"github.com/nephila016/emailchecker/internal/report"
+	"github.com/nephila016/emailchecker/internal/verifier"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reportFormat    string
+	reportStatus    []string
+	reportDomain    []string
+	reportFilter    string
+	reportTop       int
+	reportDelimiter string
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report <results file>",
+	Short: "Summarize a results file",
+	Long: `Summarize the results of an earlier run: the totals per status shown at
+the end of a bulk run, plus breakdowns by sub-status, domain, MX host,
+mailbox provider and reason, and verification latency.
+
+The file can be any results file written by bulk or check: CSV, JSON, JSONL
+or a SQLite database. CSV needs at least the email and status fields. The
+format is detected from the content; - reads stdin.
+
+--status and --domain narrow the results; --filter takes any expression
+that --split-bucket accepts. They combine with AND.
+
+Examples:
+  emailchecker report results.csv
+  emailchecker report results.db --status invalid,risky --top 20
+  emailchecker report results.jsonl --domain '*.edu' --format markdown
+  emailchecker report results.json --filter 'catch_all && score<50' --format json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runReport,
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+
+	reportCmd.Flags().StringVar(&reportFormat, "format", "table", "Output format: table, json or markdown")
+	reportCmd.Flags().StringSliceVar(&reportStatus, "status", nil, "Only results with these statuses (valid, invalid, unknown, risky, error)")
+	reportCmd.Flags().StringSliceVar(&reportDomain, "domain", nil, "Only results for these domains; * and ? match any characters")
+	reportCmd.Flags().StringVar(&reportFilter, "filter", "", "Only results matching a filter expression, e.g. 'catch_all && score<50'")
+	reportCmd.Flags().IntVar(&reportTop, "top", 10, "Entries per breakdown (0 = all)")
+	reportCmd.Flags().StringVar(&reportDelimiter, "delimiter", "", "CSV delimiter (default: detected from the header)")
+}
+
+// reportOutput is the JSON form of a report
+type reportOutput struct {
+	File   string `json:"file"`
+	Filter string `json:"filter,omitempty"`
+	*report.Report
+}
+
+func runReport(cmd *cobra.Command, args []string) error {
+	switch reportFormat {
+	case "table", "json", "markdown", "md":
+	default:
+		return fmt.Errorf("unknown format %q (use table, json or markdown)", reportFormat)
+	}
+
+	filter, err := reportFilterExpr(reportStatus, reportDomain, reportFilter)
+	if err != nil {
+		return err
+	}
+	delim, err := output.ParseDelimiter(reportDelimiter)
+	if err != nil {
+		return err
+	}
+
+	filename := args[0]
+	stats := report.New()
+	read := 0
+	err = report.ReadFile(filename, report.ReadOptions{Delimiter: delim}, func(r *verifier.Result) error {
+		read++
+		if filter == nil || filter.Match(r) {
+			stats.Add(r)
+		}
+		return nil
+	})
+	if errors.Is(err, report.ErrTruncated) {
+		color.New(color.FgYellow).Fprintf(os.Stderr, "Warning: %s ends early (interrupted run?); read %d complete results\n", filename, read)
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+	if read == 0 {
+		return fmt.Errorf("no results in %s", filename)
+	}
+
+	out := reportOutput{File: filename, Report: stats.Report(reportTop)}
+	if filter != nil {
+		out.Filter = filter.String()
+	}
+	switch reportFormat {
+	case "json":
+		return printJSON(out)
+	case "markdown", "md":
+		printReportMarkdown(out)
+	default:
+		printReportTable(out)
+	}
+	return nil
+}
+
+// reportFilterExpr combines the filter flags into one filter, nil when none
+// is set
+func reportFilterExpr(statuses, domains []string, expr string) (*output.Filter, error) {
+	var parts []string
+	if len(statuses) > 0 {
+		conds := make([]string, 0, len(statuses))
+		for _, s := range statuses {
+			s = strings.ToLower(strings.TrimSpace(s))
+			switch verifier.Status(s) {
+			case verifier.StatusValid, verifier.StatusInvalid, verifier.StatusUnknown, verifier.StatusRisky, verifier.StatusError:
+			default:
+				return nil, fmt.Errorf("unknown status %q (use valid, invalid, unknown, risky or error)", s)
+			}
+			conds = append(conds, "status="+s)
+		}
+		parts = append(parts, "("+strings.Join(conds, " || ")+")")
+	}
+	if len(domains) > 0 {
+		conds := make([]string, 0, len(domains))
+		for _, d := range domains {
+			conds = append(conds, "domain="+quoteFilterValue(strings.TrimSpace(d)))
+		}
+		parts = append(parts, "("+strings.Join(conds, " || ")+")")
+	}
+	if expr != "" {
+		parts = append(parts, "("+expr+")")
+	}
+	if len(parts) == 0 {
+		return nil, nil
+	}
+	return output.ParseFilter(strings.Join(parts, " && "))
+}
+
+// quoteFilterValue quotes a value for a filter expression
+func quoteFilterValue(s string) string {
+	if strings.Contains(s, "'") {
+		return `"` + s + `"`
+	}
+	return "'" + s + "'"
+}
+
+// printReportTable prints the report for the terminal, starting with the
+// same summary as a bulk run
+func printReportTable(out reportOutput) {
+	r := out.Report
+	green := color.New(color.FgGreen)
+	red := color.New(color.FgRed)
+	yellow := color.New(color.FgYellow)
+	cyan := color.New(color.FgCyan)
+	bold := color.New(color.Bold)
+
+	fmt.Println()
+	cyan.Println("========================================")
+	cyan.Println("              SUMMARY")
+	cyan.Println("========================================")
+	fmt.Println()
+
+	fmt.Printf("File:              %s\n", out.File)
+	if out.Filter != "" {
+		fmt.Printf("Filter:            %s\n", out.Filter)
+	}
+	fmt.Println()
+	fmt.Printf("Total Verified:    %d\n", r.Total)
+	green.Printf("Valid:             %-8d %s\n", r.Valid, percent(r.Share(r.Valid)))
+	red.Printf("Invalid:           %-8d %s\n", r.Invalid, percent(r.Share(r.Invalid)))
+	yellow.Printf("Unknown:           %-8d %s\n", r.Unknown, percent(r.Share(r.Unknown)))
+	yellow.Printf("Risky:             %-8d %s\n", r.Risky, percent(r.Share(r.Risky)))
+	red.Printf("Errors:            %-8d %s\n", r.Errors, percent(r.Share(r.Errors)))
+	fmt.Println()
+	fmt.Printf("Catch-all:         %-8d %s\n", r.CatchAll, percent(r.Share(r.CatchAll)))
+	fmt.Printf("Disposable:        %-8d %s\n", r.Disposable, percent(r.Share(r.Disposable)))
+	fmt.Printf("Role accounts:     %-8d %s\n", r.RoleAccount, percent(r.Share(r.RoleAccount)))
+	fmt.Printf("Free providers:    %-8d %s\n", r.FreeProvider, percent(r.Share(r.FreeProvider)))
+	if !r.FirstVerified.IsZero() {
+		fmt.Println()
+		span := r.LastVerified.Sub(r.FirstVerified)
+		fmt.Printf("Verified:          %s to %s\n", r.FirstVerified.Local().Format("2006-01-02 15:04:05"), r.LastVerified.Local().Format("2006-01-02 15:04:05"))
+		if span >= time.Second {
+			fmt.Printf("Duration:          %s\n", span.Round(time.Second))
+			fmt.Printf("Rate:              %.2f emails/sec\n", float64(r.Total)/span.Seconds())
+		}
+	}
+
+	l := r.Latency
+	fmt.Println()
+	bold.Println("Latency")
+	fmt.Printf("  min %d ms, median %d ms, mean %d ms, p90 %d ms, p99 %d ms, max %d ms\n", l.Min, l.P50, l.Mean, l.P90, l.P99, l.Max)
+	for _, b := range l.Buckets {
+		fmt.Printf("  %-10s %8d %7s  %s\n", b.Label, b.Count, percent(b.Share), strings.Repeat("#", int(b.Share*40+0.5)))
+	}
+
+	printItemsTable(bold, "Sub-status", r.SubStatuses)
+	printGroupsTable(bold, "Domains", "domain", r.Domains)
+	printGroupsTable(bold, fmt.Sprintf("Domains by invalid rate (at least %d results)", report.MinRateResults), "domain", r.InvalidDomains)
+	printGroupsTable(bold, "MX hosts", "mx host", r.MXHosts)
+	printGroupsTable(bold, "Mailbox providers", "provider", r.Providers)
+	printItemsTable(bold, "Reasons", r.Reasons)
+	fmt.Println()
+}
+
+func printItemsTable(title *color.Color, name string, items []report.Item) {
+	if len(items) == 0 {
+		return
+	}
+	width := nameWidth(len(name), len(items), func(i int) string { return items[i].Name })
+	fmt.Println()
+	title.Println(name)
+	for _, it := range items {
+		fmt.Printf("  %-*s %8d %7s\n", width, clip(it.Name, width), it.Count, percent(it.Share))
+	}
+}
+
+func printGroupsTable(title *color.Color, name, column string, groups []report.Group) {
+	if len(groups) == 0 {
+		return
+	}
+	width := nameWidth(len(column), len(groups), func(i int) string { return groups[i].Name })
+	fmt.Println()
+	title.Println(name)
+	fmt.Printf("  %-*s %8s %8s %8s %8s %8s %8s %8s\n", width, column, "total", "valid", "invalid", "risky", "unknown", "errors", "invalid%")
+	for _, g := range groups {
+		fmt.Printf("  %-*s %8d %8d %8d %8d %8d %8d %8s\n", width, clip(g.Name, width),
+			g.Total, g.Valid, g.Invalid, g.Risky, g.Unknown, g.Errors, percent(g.InvalidRate))
+	}
+}
+
+// reportNameWidth caps the name column of report tables
+const reportNameWidth = 48
+
+// nameWidth is the width of a name column: the longest name, capped
+func nameWidth(min, n int, name func(i int) string) int {
+	width := min
+	for i := 0; i < n; i++ {
+		if l := len([]rune(name(i))); l > width {
+			width = l
+		}
+	}
+	if width > reportNameWidth {
+		width = reportNameWidth
+	}
+	return width
+}
+
+// clip shortens s to width runes, marking the cut with "..."
+func clip(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	return string(runes[:width-3]) + "..."
+}
+
+func percent(f float64) string {
+	return fmt.Sprintf("%.1f%%", f*100)
+}
+
+// printReportMarkdown prints the report as Markdown tables, for tickets
+func printReportMarkdown(out reportOutput) {
+	r := out.Report
+	fmt.Printf("## Verification report: %s\n\n", mdEscape(out.File))
+	if out.Filter != "" {
+		fmt.Printf("Filter: `%s`\n\n", strings.ReplaceAll(out.Filter, "`", "'"))
+	}
+	if !r.FirstVerified.IsZero() {
+		fmt.Printf("Verified %s to %s\n\n", r.FirstVerified.Local().Format("2006-01-02 15:04:05"), r.LastVerified.Local().Format("2006-01-02 15:04:05"))
+	}
+
+	fmt.Println("| Status | Count | Share |")
+	fmt.Println("|---|---:|---:|")
+	fmt.Printf("| **Total** | **%d** | |\n", r.Total)
+	for _, s := range []struct {
+		name  string
+		count int
+	}{
+		{"Valid", r.Valid}, {"Invalid", r.Invalid}, {"Unknown", r.Unknown}, {"Risky", r.Risky}, {"Errors", r.Errors},
+		{"Catch-all", r.CatchAll}, {"Disposable", r.Disposable}, {"Role accounts", r.RoleAccount}, {"Free providers", r.FreeProvider},
+	} {
+		fmt.Printf("| %s | %d | %s |\n", s.name, s.count, percent(r.Share(s.count)))
+	}
+
+	l := r.Latency
+	fmt.Println("\n### Latency")
+	fmt.Println()
+	fmt.Println("| Min | Median | Mean | p90 | p99 | Max |")
+	fmt.Println("|---:|---:|---:|---:|---:|---:|")
+	fmt.Printf("| %d ms | %d ms | %d ms | %d ms | %d ms | %d ms |\n", l.Min, l.P50, l.Mean, l.P90, l.P99, l.Max)
+	fmt.Println()
+	fmt.Println("| Range | Count | Share |")
+	fmt.Println("|---|---:|---:|")
+	for _, b := range l.Buckets {
+		fmt.Printf("| %s | %d | %s |\n", b.Label, b.Count, percent(b.Share))
+	}
+
+	printItemsMarkdown("Sub-status", "Sub-status", r.SubStatuses)
+	printGroupsMarkdown("Domains", "Domain", r.Domains)
+	printGroupsMarkdown(fmt.Sprintf("Domains by invalid rate (at least %d results)", report.MinRateResults), "Domain", r.InvalidDomains)
+	printGroupsMarkdown("MX hosts", "MX host", r.MXHosts)
+	printGroupsMarkdown("Mailbox providers", "Provider", r.Providers)
+	printItemsMarkdown("Reasons", "Reason", r.Reasons)
+}
+
+func printItemsMarkdown(title, column string, items []report.Item) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Printf("\n### %s\n\n", title)
+	fmt.Printf("| %s | Count | Share |\n", column)
+	fmt.Println("|---|---:|---:|")
+	for _, it := range items {
+		fmt.Printf("| %s | %d | %s |\n", mdEscape(it.Name), it.Count, percent(it.Share))
+	}
+}
+
+func printGroupsMarkdown(title, column string, groups []report.Group) {
+	if len(groups) == 0 {
+		return
+	}
+	fmt.Printf("\n### %s\n\n", title)
+	fmt.Printf("| %s | Total | Valid | Invalid | Risky | Unknown | Errors | Invalid %% |\n", column)
+	fmt.Println("|---|---:|---:|---:|---:|---:|---:|---:|")
+	for _, g := range groups {
+		fmt.Printf("| %s | %d | %d | %d | %d | %d | %d | %s |\n", mdEscape(g.Name),
+			g.Total, g.Valid, g.Invalid, g.Risky, g.Unknown, g.Errors, percent(g.InvalidRate))
+	}
+}
+
+// mdEscape keeps a value from breaking a Markdown table
+func mdEscape(s string) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	return strings.ReplaceAll(s, "|", `\|`)
+}
@@ -45,7 +45,8 @@ Examples:
 		if err := debug.Init(level, debugFile, !noColor); err != nil {
 			return err
 		}
-		return nil
+		loadClassifierLists(cmd)
+		return loadClassifierRules()
 	},
 	PersistentPostRun: func(cmd *cobra.Command, args []string) {
 		debug.Close()
@@ -0,0 +1,293 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/nephila016/emailchecker/internal/report"
+	"github.com/nephila016/emailchecker/internal/verifier"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	scoreProfile string
+	scoreJSON    bool
+	scoreEmail   string
+	scoreLimit   int
+)
+
+var scoreCmd = &cobra.Command{
+	Use:   "score",
+	Short: "Inspect the confidence scoring model",
+	Long: `Inspect the model that turns verification results into confidence_score.
+
+A scoring profile assigns points to factors (syntax_valid, has_mx,
+status_valid, catch_all, disposable, ...) and caps the total with rules.
+Built-in profiles are default, strict and lenient; more can be defined in
+.emailchecker.yaml. Configured profiles start from the default profile: their
+weights replace the default weights and their rules are added to the
+default rules.
+
+Example configuration:
+  scoring:
+    profile: sales            # used when --score-profile is not given
+    profiles:
+      sales:
+        weights:
+          role_account: -30
+          free_provider: -10
+        rules:
+          - name: no_catch_all
+            when: [catch_all]
+            cap: 40
+
+Examples:
+  emailchecker score profiles
+  emailchecker score explain results.jsonl
+  emailchecker score explain results.json --score-profile strict --email user@example.com`,
+}
+
+var scoreProfilesCmd = &cobra.Command{
+	Use:   "profiles",
+	Short: "List scoring profiles and their weights",
+	Args:  cobra.NoArgs,
+	RunE:  runScoreProfiles,
+}
+
+var scoreExplainCmd = &cobra.Command{
+	Use:   "explain <results file>",
+	Short: "Show how each result's confidence score is made up",
+	Long: `Recompute confidence scores for a JSON, JSONL or SQLite results file and
+show which factors contributed how many points. Use --score-profile to see
+how a different profile would score the same results.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runScoreExplain,
+}
+
+func init() {
+	rootCmd.AddCommand(scoreCmd)
+	scoreCmd.AddCommand(scoreProfilesCmd, scoreExplainCmd)
+
+	scoreCmd.PersistentFlags().BoolVar(&scoreJSON, "json", false, "Output as JSON")
+	scoreExplainCmd.Flags().StringVar(&scoreProfile, "score-profile", "", "Scoring profile (default from config, else \"default\")")
+	scoreExplainCmd.Flags().StringVar(&scoreEmail, "email", "", "Only explain this address")
+	scoreExplainCmd.Flags().IntVar(&scoreLimit, "limit", 20, "Maximum results to show individually (0 = all)")
+}
+
+// scoringModel resolves a profile name (or the configured default) to a
+// scoring model. Configured profiles take precedence over built-in ones.
+func scoringModel(name string) (*verifier.ScoringModel, error) {
+	if name == "" {
+		name = viper.GetString("scoring.profile")
+	}
+	if name == "" {
+		name = "default"
+	}
+
+	var configured map[string]*verifier.ScoringModel
+	if viper.IsSet("scoring.profiles") {
+		if err := viper.UnmarshalKey("scoring.profiles", &configured); err != nil {
+			return nil, fmt.Errorf("invalid scoring configuration: %w", err)
+		}
+	}
+
+	var model *verifier.ScoringModel
+	if over, ok := configured[name]; ok {
+		model = verifier.DefaultScoringModel().Merge(over)
+	} else if builtin, ok := verifier.BuiltinScoringProfile(name); ok {
+		model = builtin
+	} else {
+		names := verifier.ScoringProfileNames()
+		for n := range configured {
+			names = append(names, n)
+		}
+		return nil, fmt.Errorf("unknown scoring profile %q (available: %s)", name, strings.Join(names, ", "))
+	}
+	model.Name = name
+
+	if err := model.Validate(); err != nil {
+		return nil, err
+	}
+	return model, nil
+}
+
+func runScoreProfiles(cmd *cobra.Command, args []string) error {
+	names := verifier.ScoringProfileNames()
+	var configured map[string]interface{}
+	viper.UnmarshalKey("scoring.profiles", &configured) //nolint:errcheck // reported by scoringModel below
+	for n := range configured {
+		if _, ok := verifier.BuiltinScoringProfile(n); !ok {
+			names = append(names, n)
+		}
+	}
+
+	models := make([]*verifier.ScoringModel, 0, len(names))
+	for _, n := range names {
+		m, err := scoringModel(n)
+		if err != nil {
+			return err
+		}
+		models = append(models, m)
+	}
+
+	if scoreJSON {
+		return printJSON(models)
+	}
+
+	cyan := color.New(color.FgCyan, color.Bold)
+	active := viper.GetString("scoring.profile")
+	if active == "" {
+		active = "default"
+	}
+	for _, m := range models {
+		fmt.Println()
+		marker := ""
+		if m.Name == active {
+			marker = " (active)"
+		}
+		cyan.Printf("%s%s\n", m.Name, marker)
+		for _, factor := range verifier.ScoreFactors() {
+			if w, ok := m.Weights[factor]; ok && w != 0 {
+				fmt.Printf("  %-16s %+d\n", factor, w)
+			}
+		}
+		for _, rule := range m.Rules {
+			fmt.Printf("  rule %-11s cap %d when %s\n", rule.Name, rule.Cap, strings.Join(rule.When, " and "))
+		}
+	}
+	fmt.Println()
+	return nil
+}
+
+// explainedResult is one entry of 'score explain --json'
+type explainedResult struct {
+	Email       string                       `json:"email"`
+	Status      verifier.Status              `json:"status"`
+	StoredScore int                          `json:"stored_score"`
+	Score       int                          `json:"score"`
+	Breakdown   []verifier.ScoreContribution `json:"breakdown"`
+}
+
+func runScoreExplain(cmd *cobra.Command, args []string) error {
+	model, err := scoringModel(scoreProfile)
+	if err != nil {
+		return err
+	}
+
+	results, err := readResultsFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	explained := make([]explainedResult, 0, len(results))
+	for _, r := range results {
+		if scoreEmail != "" && !strings.EqualFold(r.Email, scoreEmail) {
+			continue
+		}
+		score, breakdown := model.Score(r)
+		explained = append(explained, explainedResult{
+			Email:       r.Email,
+			Status:      r.Status,
+			StoredScore: r.ConfidenceScore,
+			Score:       score,
+			Breakdown:   breakdown,
+		})
+	}
+	if scoreEmail != "" && len(explained) == 0 {
+		return fmt.Errorf("%s not found in %s", scoreEmail, args[0])
+	}
+
+	if scoreJSON {
+		return printJSON(explained)
+	}
+
+	cyan := color.New(color.FgCyan, color.Bold)
+	green := color.New(color.FgGreen)
+	red := color.New(color.FgRed)
+	yellow := color.New(color.FgYellow)
+
+	fmt.Println()
+	cyan.Printf("Profile: %s  (%d results)\n", model.Name, len(explained))
+
+	shown := explained
+	if scoreLimit > 0 && len(shown) > scoreLimit {
+		shown = shown[:scoreLimit]
+	}
+	for _, e := range shown {
+		fmt.Println()
+		changed := ""
+		if e.Score != e.StoredScore {
+			changed = yellow.Sprintf(" (was %d)", e.StoredScore)
+		}
+		fmt.Printf("%s  [%s]  score %d%s\n", e.Email, e.Status, e.Score, changed)
+		for _, c := range e.Breakdown {
+			points := fmt.Sprintf("%+4d", c.Points)
+			if c.Points >= 0 {
+				points = green.Sprint(points)
+			} else {
+				points = red.Sprint(points)
+			}
+			fmt.Printf("  %s  %s\n", points, c.Factor)
+		}
+	}
+	if len(shown) < len(explained) {
+		fmt.Printf("\n... and %d more (use --limit 0 to show all)\n", len(explained)-len(shown))
+	}
+
+	// Factor summary across all results
+	type factorTotal struct {
+		factor string
+		count  int
+		points int
+	}
+	totals := make(map[string]*factorTotal)
+	for _, e := range explained {
+		for _, c := range e.Breakdown {
+			t, ok := totals[c.Factor]
+			if !ok {
+				t = &factorTotal{factor: c.Factor}
+				totals[c.Factor] = t
+			}
+			t.count++
+			t.points += c.Points
+		}
+	}
+	summary := make([]*factorTotal, 0, len(totals))
+	for _, t := range totals {
+		summary = append(summary, t)
+	}
+	sort.Slice(summary, func(i, j int) bool { return summary[i].count > summary[j].count })
+
+	if len(explained) > 1 {
+		fmt.Println()
+		cyan.Println("Factors:")
+		fmt.Printf("  %-20s %8s %10s\n", "factor", "results", "avg points")
+		for _, t := range summary {
+			fmt.Printf("  %-20s %8d %+10.1f\n", t.factor, t.count, float64(t.points)/float64(t.count))
+		}
+	}
+	fmt.Println()
+	return nil
+}
+
+// readResultsFile loads results written by the JSON, JSONL or SQLite
+// writers, which keep the full result
+func readResultsFile(filename string) ([]*verifier.Result, error) {
+	var results []*verifier.Result
+	err := report.ReadFile(filename, report.ReadOptions{
+		Formats: []report.Format{report.FormatJSON, report.FormatJSONL, report.FormatSQLite},
+	}, func(r *verifier.Result) error {
+		results = append(results, r)
+		return nil
+	})
+	if errors.Is(err, report.ErrTruncated) {
+		color.New(color.FgYellow).Fprintf(os.Stderr, "Warning: %s ends early (interrupted run?); read %d complete results\n", filename, len(results))
+		err = nil
+	}
+	return results, err
+}
@@ -1,5 +1,10 @@
 package classifier
 
+import (
+	"sort"
+	"sync"
+)
+
 // ClassificationResult contains all classification results
 type ClassificationResult struct {
 	Disposable   bool
@@ -15,3 +20,76 @@ func Classify(localPart, domain string) *ClassificationResult {
 		FreeProvider: IsFreeProvider(domain),
 	}
 }
+
+// Input is the information about an address available to a Classifier
+type Input struct {
+	LocalPart string
+	Domain    string
+	MXHosts   []string
+}
+
+// Classifier assigns free-form tags to an address (e.g. "competitor",
+// "internal", "high_risk_tld"). Implementations must be safe for concurrent
+// use.
+type Classifier interface {
+	// Name identifies the classifier in the registry
+	Name() string
+	// Classify returns the tags that apply to in, or nil
+	Classify(in *Input) []string
+}
+
+// registry holds classifiers by name
+var registry = struct {
+	sync.RWMutex
+	byName map[string]Classifier
+}{byName: make(map[string]Classifier)}
+
+// Register adds c to the registry, replacing any classifier with the same name
+func Register(c Classifier) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.byName[c.Name()] = c
+}
+
+// Unregister removes the classifier called name from the registry
+func Unregister(name string) {
+	registry.Lock()
+	defer registry.Unlock()
+	delete(registry.byName, name)
+}
+
+// Get returns the registered classifier called name
+func Get(name string) (Classifier, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	c, ok := registry.byName[name]
+	return c, ok
+}
+
+// Registered returns every registered classifier, sorted by name
+func Registered() []Classifier {
+	registry.RLock()
+	defer registry.RUnlock()
+	out := make([]Classifier, 0, len(registry.byName))
+	for _, c := range registry.byName {
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name() < out[j].Name() })
+	return out
+}
+
+// RunClassifiers applies every classifier to in and returns the union of
+// their tags, in classifier order without duplicates
+func RunClassifiers(classifiers []Classifier, in *Input) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, c := range classifiers {
+		for _, tag := range c.Classify(in) {
+			if tag != "" && !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
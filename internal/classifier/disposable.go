@@ -1,7 +1,10 @@
 package classifier
 
 import (
+	"fmt"
 	"strings"
+
+	"golang.org/x/net/publicsuffix"
 )
 
 // Disposable domain list (deduplicated, ~500 domains)
@@ -642,13 +645,87 @@ var disposableDomains = map[string]bool{
 	"zomg.info":             true,
 }
 
+// DisposableMatch records which list entry classified a domain as disposable
+type DisposableMatch struct {
+	Entry  string // list entry that matched, e.g. "yopmail.com" or "*.33mail.com"
+	Source string // "builtin", a file path or a URL
+	Via    string // DisposableViaDomainList or DisposableViaMXFingerprint
+}
+
+// String returns a human-readable description of the match
+func (m *DisposableMatch) String() string {
+	if m.Via == DisposableViaMXFingerprint {
+		return fmt.Sprintf("MX fingerprint %q (%s)", m.Entry, m.Source)
+	}
+	return fmt.Sprintf("list entry %q (%s)", m.Entry, m.Source)
+}
+
 // IsDisposable checks if domain is a disposable email provider
 func IsDisposable(domain string) bool {
-	domain = strings.ToLower(strings.TrimSpace(domain))
-	return disposableDomains[domain]
+	return MatchDisposable(domain) != nil
+}
+
+// MatchDisposable returns the list entry that makes domain disposable, or
+// nil. Entries match the domain itself and any subdomain of it, up to the
+// registrable domain (so "abc.yopmail.com" matches "yopmail.com", but a
+// "co.uk" entry never matches "example.co.uk"). Wildcard entries such as
+// "*.33mail.com" are matched against the full domain. The most specific
+// name wins: an allow-listed subdomain of a disposable domain is clean.
+func MatchDisposable(domain string) *DisposableMatch {
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	if domain == "" {
+		return nil
+	}
+
+	for _, name := range registrableParents(domain) {
+		if allowed(ListDisposable, name) {
+			return nil
+		}
+		if ok, src := listed(ListDisposable, name); ok {
+			return &DisposableMatch{Entry: name, Source: src, Via: DisposableViaDomainList}
+		}
+	}
+
+	if p, ok := matchPattern(ListDisposable, domain); ok {
+		return &DisposableMatch{Entry: p.pattern, Source: p.source, Via: DisposableViaDomainList}
+	}
+
+	return nil
+}
+
+// registrableDomain returns the eTLD+1 of name, or "" if it has none
+func registrableDomain(name string) string {
+	registrable, err := publicsuffix.EffectiveTLDPlusOne(name)
+	if err != nil {
+		return ""
+	}
+	return registrable
+}
+
+// registrableParents returns domain followed by each parent domain down to
+// and including its registrable domain (eTLD+1) per the Public Suffix List.
+// If the registrable domain cannot be determined only domain is returned.
+func registrableParents(domain string) []string {
+	names := []string{domain}
+
+	registrable, err := publicsuffix.EffectiveTLDPlusOne(domain)
+	if err != nil || registrable == domain {
+		return names
+	}
+
+	for name := domain; name != registrable; {
+		i := strings.IndexByte(name, '.')
+		if i < 0 {
+			break
+		}
+		name = name[i+1:]
+		names = append(names, name)
+	}
+	return names
 }
 
 // GetDisposableCount returns the number of disposable domains in the list
+// (built-in plus loaded)
 func GetDisposableCount() int {
-	return len(disposableDomains)
+	return listCount(ListDisposable)
 }
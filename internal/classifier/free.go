@@ -190,10 +190,12 @@ var freeProviders = map[string]bool{
 // IsFreeProvider checks if domain is a free email provider
 func IsFreeProvider(domain string) bool {
 	domain = strings.ToLower(strings.TrimSpace(domain))
-	return freeProviders[domain]
+	match, _ := listed(ListFree, domain)
+	return match
 }
 
 // GetFreeProviderCount returns the number of free providers in the list
+// (built-in plus loaded)
 func GetFreeProviderCount() int {
-	return len(freeProviders)
+	return listCount(ListFree)
 }
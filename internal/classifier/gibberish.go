@@ -0,0 +1,126 @@
+package classifier
+
+import (
+	"strings"
+)
+
+// GeneratedThreshold is the LocalPartScore below which a local part is
+// considered machine-generated
+const GeneratedThreshold = 50
+
+// commonBigrams are the most frequent letter pairs in English text and
+// Western personal names. Human-chosen local parts are mostly built from
+// them; random strings such as "xk3j9qz8vp" rarely are.
+var commonBigrams = func() map[string]bool {
+	const list = "th he in er an re on at en nd ti es or te of ed is it al ar st to nt ng " +
+		"se ha as ou io le ve co me de hi ri ro ic ne ea ra ce li ch ll be ma si om ur " +
+		"ca el ta la ns di fo ho pe ec pr no ct us ac ot il tr ly nc et ut ss so rs un " +
+		"lo wa ge ie wh ee wi em ad ol rt po we na ul ni ts mo ow pa im mi ai sh ir su " +
+		"id os iv ia am fi ci vi pl ig tu ev ld ry mp fe bl ab gh ty op wo sa ay ex ke " +
+		"fr oo av ag if ap gr od bo sp rd do uc bu ei ov by rm ep tt oc fa ef cu rn sc " +
+		"gi da yo cr cl du ga qu ue ff ba ey ls va um pp ua up lu go ht ru ug ds lt pi " +
+		"rc rr eg au ck ew mu br bi pt ak pu ui rg ib tl ny ki rk ys ob mm fu ph og ms " +
+		"ye ud mb ip ub oi rl gu dr hr cc tw ft wn nu af hu nn eo vo rv nf sm fl iz ok " +
+		"my gl aw ju oa sy sl ps jo lf ja je ka ko ku ya yu za ze zi zo ov ev ki ah eh " +
+		"oh uh dy ry ny ly ey ck sk ks"
+	set := make(map[string]bool)
+	for _, b := range strings.Fields(list) {
+		set[b] = true
+	}
+	return set
+}()
+
+// ScoreLocalPart estimates how human-like localPart is, from 0 (machine
+// generated) to 100 (name-like). It looks at letter/digit alternation,
+// consonant clusters, vowel ratio and how many letter pairs are common in
+// natural language. Sub-address tags are ignored and very short local parts
+// score high since there is too little to judge.
+func ScoreLocalPart(localPart string) int {
+	localPart = stripTag(strings.ToLower(strings.TrimSpace(localPart)), '+')
+
+	var letters, digits, vowels, switches, run, maxRun int
+	var bigrams, common int
+	prevKind := 0 // 0 = none, 1 = letter, 2 = digit
+	var prev byte
+	for i := 0; i < len(localPart); i++ {
+		c := localPart[i]
+		kind := 0
+		switch {
+		case c >= 'a' && c <= 'z':
+			kind = 1
+			letters++
+			if strings.IndexByte("aeiouy", c) >= 0 {
+				vowels++
+				run = 0
+			} else {
+				run++
+				if run > maxRun {
+					maxRun = run
+				}
+			}
+			if prevKind == 1 {
+				bigrams++
+				if commonBigrams[string([]byte{prev, c})] {
+					common++
+				}
+			}
+		case c >= '0' && c <= '9':
+			kind = 2
+			digits++
+			run = 0
+		default:
+			run = 0
+		}
+		if kind != 0 && prevKind != 0 && kind != prevKind {
+			switches++
+		}
+		prevKind, prev = kind, c
+	}
+
+	if letters+digits < 4 {
+		return 100
+	}
+
+	score := 100.0
+
+	// Names take at most a trailing number ("john.smith85") or a year in
+	// the middle; random tokens alternate constantly
+	if switches > 2 {
+		score -= float64(switches-2) * 20
+	}
+
+	if letters >= 4 {
+		// Up to four consonants occur in real names ("schwarz", "tsch")
+		if maxRun > 4 {
+			score -= float64(maxRun-4) * 20
+		}
+		if ratio := float64(vowels) / float64(letters); ratio < 0.2 {
+			score -= 30
+		}
+		if bigrams >= 3 {
+			if ratio := float64(common) / float64(bigrams); ratio < 0.5 {
+				score -= (0.5 - ratio) * 120
+			}
+		}
+	}
+
+	// Mostly digits: phone numbers are human, long random numbers are not
+	if letters > 0 && digits > letters && digits >= 8 {
+		score -= 20
+	}
+
+	// Long unbroken strings with no separator are typical of generators
+	if len(localPart) >= 16 && !strings.ContainsAny(localPart, "._-") {
+		score -= 20
+	}
+
+	if score < 0 {
+		score = 0
+	}
+	return int(score + 0.5)
+}
+
+// IsGenerated returns true if localPart looks machine-generated
+func IsGenerated(localPart string) bool {
+	return ScoreLocalPart(localPart) < GeneratedThreshold
+}
@@ -0,0 +1,534 @@
+package classifier
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ListKind identifies one of the classifier lists
+type ListKind string
+
+const (
+	ListDisposable ListKind = "disposable"
+	ListFree       ListKind = "free"
+	ListRole       ListKind = "role"
+	// ListDisposableMX holds MX host suffixes and CIDR netblocks of
+	// disposable mail services
+	ListDisposableMX ListKind = "disposable_mx"
+)
+
+// ListKinds is every list kind, in display order
+var ListKinds = []ListKind{ListDisposable, ListDisposableMX, ListFree, ListRole}
+
+// maxListSize limits how much is read from a single list source
+const maxListSize = 32 * 1024 * 1024 // 32 MB
+
+// listFetchTimeout bounds a single list download
+const listFetchTimeout = 60 * time.Second
+
+// ListConfig describes the external lists to merge with the built-ins
+type ListConfig struct {
+	// CacheDir holds downloaded copies of URL sources
+	CacheDir string
+	// Sources are file paths or http(s) URLs, one entry per line
+	Sources map[ListKind][]string
+	// Allow entries are never reported as matching, even if built in
+	Allow map[ListKind][]string
+}
+
+// DefaultListCacheDir returns the per-user cache directory for lists
+func DefaultListCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "emailchecker", "lists")
+}
+
+// ListSourceInfo describes one loaded (or failed) list source
+type ListSourceInfo struct {
+	Kind      ListKind  `json:"kind"`
+	Source    string    `json:"source"`
+	Entries   int       `json:"entries"`
+	Invalid   int       `json:"invalid"`
+	Version   string    `json:"version"`
+	FetchedAt time.Time `json:"fetched_at"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// lists holds entries loaded from external sources on top of the built-in
+// maps. Lookups take the read lock; LoadLists replaces everything at once.
+var lists struct {
+	sync.RWMutex
+	extra     map[ListKind]map[string]string // entry -> source it came from
+	patterns  map[ListKind][]listPattern     // wildcard entries, e.g. "*.33mail.com"
+	netblocks map[ListKind][]listNetblock    // CIDR entries, e.g. "192.0.2.0/24"
+	allow     map[ListKind]map[string]bool
+	roleKeys  map[string]string // roleKey(entry) -> source, for loaded role entries
+	sources   []*ListSourceInfo
+}
+
+// listNetblock is a CIDR list entry
+type listNetblock struct {
+	network *net.IPNet
+	source  string
+}
+
+// listPattern is a wildcard list entry matched with path.Match
+type listPattern struct {
+	pattern string
+	source  string
+}
+
+// builtinList returns the compiled-in map for kind
+func builtinList(kind ListKind) map[string]bool {
+	switch kind {
+	case ListDisposable:
+		return disposableDomains
+	case ListFree:
+		return freeProviders
+	case ListDisposableMX:
+		return disposableMXHosts
+	default:
+		return rolePrefixes
+	}
+}
+
+// listed reports whether entry is on the kind list (built-in or loaded) and
+// not allow-listed, along with the source that matched.
+func listed(kind ListKind, entry string) (bool, string) {
+	lists.RLock()
+	defer lists.RUnlock()
+
+	if lists.allow[kind][entry] {
+		return false, ""
+	}
+	if builtinList(kind)[entry] {
+		return true, "builtin"
+	}
+	if src, ok := lists.extra[kind][entry]; ok {
+		return true, src
+	}
+	return false, ""
+}
+
+// Lookup reports whether entry is on the kind list and which source matched
+// ("builtin", a file path or a URL). Allow-listed entries never match.
+func Lookup(kind ListKind, entry string) (bool, string) {
+	return listed(kind, normalizeListEntry(entry))
+}
+
+// allowed reports whether entry is on the kind allow-list
+func allowed(kind ListKind, entry string) bool {
+	lists.RLock()
+	defer lists.RUnlock()
+	return lists.allow[kind][entry]
+}
+
+// loadedRoleKey returns the source of the loaded role entry whose
+// normalized form is key
+func loadedRoleKey(key string) (string, bool) {
+	lists.RLock()
+	defer lists.RUnlock()
+	src, ok := lists.roleKeys[key]
+	return src, ok
+}
+
+// listCount returns the number of distinct entries for kind
+func listCount(kind ListKind) int {
+	lists.RLock()
+	defer lists.RUnlock()
+	builtin := builtinList(kind)
+	n := len(builtin)
+	for e := range lists.extra[kind] {
+		if !builtin[e] {
+			n++
+		}
+	}
+	return n + len(lists.patterns[kind]) + len(lists.netblocks[kind])
+}
+
+// matchNetblock returns the first CIDR entry of kind containing ip
+func matchNetblock(kind ListKind, ip net.IP) (listNetblock, bool) {
+	lists.RLock()
+	defer lists.RUnlock()
+	for _, nb := range lists.netblocks[kind] {
+		if nb.network.Contains(ip) {
+			return nb, true
+		}
+	}
+	return listNetblock{}, false
+}
+
+// matchPattern returns the first wildcard entry of kind matching entry
+func matchPattern(kind ListKind, entry string) (listPattern, bool) {
+	lists.RLock()
+	defer lists.RUnlock()
+	for _, p := range lists.patterns[kind] {
+		if ok, _ := path.Match(p.pattern, entry); ok {
+			return p, true
+		}
+	}
+	return listPattern{}, false
+}
+
+// LoadLists reads every configured source and merges it with the built-in
+// lists, replacing anything loaded previously. URL sources are read from the
+// local cache populated by UpdateLists; they are never fetched here. Sources
+// that fail to load are reported in the returned info (and the error) but do
+// not prevent the others from loading.
+func LoadLists(cfg *ListConfig) ([]*ListSourceInfo, error) {
+	extra := make(map[ListKind]map[string]string)
+	patterns := make(map[ListKind][]listPattern)
+	netblocks := make(map[ListKind][]listNetblock)
+	allow := make(map[ListKind]map[string]bool)
+	roleKeys := make(map[string]string)
+	var infos []*ListSourceInfo
+	var failed []string
+
+	for _, kind := range ListKinds {
+		extra[kind] = make(map[string]string)
+		allow[kind] = make(map[string]bool)
+
+		for _, entry := range cfg.Allow[kind] {
+			if e := normalizeListEntry(entry); e != "" {
+				allow[kind][e] = true
+			}
+		}
+
+		for _, src := range cfg.Sources[kind] {
+			info := &ListSourceInfo{Kind: kind, Source: src}
+			infos = append(infos, info)
+
+			path := src
+			if isURL(src) {
+				path = cachePath(cfg.CacheDir, kind, src)
+				if meta, err := readCacheMeta(path); err == nil {
+					info.Version = meta.Version
+					info.FetchedAt = meta.FetchedAt
+				}
+			}
+
+			entries, invalid, err := readListFile(path, kind)
+			if err != nil {
+				if isURL(src) && os.IsNotExist(err) {
+					err = fmt.Errorf("not cached yet (run 'emailchecker lists update')")
+				}
+				info.Error = err.Error()
+				failed = append(failed, src)
+				continue
+			}
+			if !isURL(src) {
+				info.Version, info.FetchedAt = fileVersion(path)
+			}
+
+			info.Entries = len(entries)
+			info.Invalid = invalid
+			for _, e := range entries {
+				if strings.Contains(e, "/") {
+					if _, network, err := net.ParseCIDR(e); err == nil {
+						netblocks[kind] = append(netblocks[kind], listNetblock{network: network, source: src})
+					}
+					continue
+				}
+				if strings.Contains(e, "*") {
+					patterns[kind] = append(patterns[kind], listPattern{pattern: e, source: src})
+					continue
+				}
+				if _, exists := extra[kind][e]; !exists {
+					extra[kind][e] = src
+				}
+				if kind == ListRole && !allow[kind][e] {
+					if _, exists := roleKeys[roleKey(e)]; !exists {
+						roleKeys[roleKey(e)] = src
+					}
+				}
+			}
+		}
+	}
+
+	lists.Lock()
+	lists.extra = extra
+	lists.patterns = patterns
+	lists.netblocks = netblocks
+	lists.allow = allow
+	lists.roleKeys = roleKeys
+	lists.sources = infos
+	lists.Unlock()
+
+	if len(failed) > 0 {
+		return infos, fmt.Errorf("failed to load %d list source(s): %s", len(failed), strings.Join(failed, ", "))
+	}
+	return infos, nil
+}
+
+// LoadedSources returns information about the sources merged by LoadLists
+func LoadedSources() []*ListSourceInfo {
+	lists.RLock()
+	defer lists.RUnlock()
+	return append([]*ListSourceInfo(nil), lists.sources...)
+}
+
+// BuiltinCount returns the number of compiled-in entries for kind
+func BuiltinCount(kind ListKind) int {
+	return len(builtinList(kind))
+}
+
+// AllowCount returns the number of allow-list entries for kind
+func AllowCount(kind ListKind) int {
+	lists.RLock()
+	defer lists.RUnlock()
+	return len(lists.allow[kind])
+}
+
+// UpdateLists downloads every URL source, validates it and stores it in the
+// cache. Local file sources are validated in place. A source that fails
+// validation keeps its previously cached copy.
+func UpdateLists(cfg *ListConfig) []*ListSourceInfo {
+	var infos []*ListSourceInfo
+	client := &http.Client{Timeout: listFetchTimeout}
+
+	for _, kind := range ListKinds {
+		for _, src := range cfg.Sources[kind] {
+			info := &ListSourceInfo{Kind: kind, Source: src}
+			infos = append(infos, info)
+
+			if !isURL(src) {
+				entries, invalid, err := readListFile(src, kind)
+				if err != nil {
+					info.Error = err.Error()
+					continue
+				}
+				info.Entries, info.Invalid = len(entries), invalid
+				info.Version, info.FetchedAt = fileVersion(src)
+				continue
+			}
+
+			if err := fetchList(client, cfg.CacheDir, kind, src, info); err != nil {
+				info.Error = err.Error()
+			}
+		}
+	}
+
+	return infos
+}
+
+// listCacheMeta is stored next to each cached list
+type listCacheMeta struct {
+	Source    string    `json:"source"`
+	Kind      ListKind  `json:"kind"`
+	Version   string    `json:"version"`
+	FetchedAt time.Time `json:"fetched_at"`
+	Entries   int       `json:"entries"`
+	Invalid   int       `json:"invalid"`
+}
+
+// fetchList downloads src, validates it and atomically replaces the cache
+func fetchList(client *http.Client, cacheDir string, kind ListKind, src string, info *ListSourceInfo) error {
+	resp, err := client.Get(src)
+	if err != nil {
+		return fmt.Errorf("fetch failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetch failed: HTTP %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxListSize+1))
+	if err != nil {
+		return fmt.Errorf("fetch failed: %w", err)
+	}
+	if len(body) > maxListSize {
+		return fmt.Errorf("list exceeds %d bytes", maxListSize)
+	}
+
+	entries, invalid := parseList(strings.NewReader(string(body)), kind)
+	if err := validateListCounts(len(entries), invalid); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(cacheDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create cache dir: %w", err)
+	}
+
+	path := cachePath(cacheDir, kind, src)
+	if err := writeFileAtomic(path, body); err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(body)
+	meta := &listCacheMeta{
+		Source:    src,
+		Kind:      kind,
+		Version:   hex.EncodeToString(sum[:])[:12],
+		FetchedAt: time.Now().UTC(),
+		Entries:   len(entries),
+		Invalid:   invalid,
+	}
+	metaJSON, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path+".json", metaJSON); err != nil {
+		return err
+	}
+
+	info.Entries, info.Invalid = meta.Entries, meta.Invalid
+	info.Version, info.FetchedAt = meta.Version, meta.FetchedAt
+	return nil
+}
+
+// validateListCounts rejects lists that are empty or mostly garbage (e.g. an
+// HTML error page served with status 200)
+func validateListCounts(valid, invalid int) error {
+	if valid == 0 {
+		return fmt.Errorf("list contains no valid entries")
+	}
+	if invalid > valid {
+		return fmt.Errorf("list rejected: %d invalid vs %d valid entries", invalid, valid)
+	}
+	return nil
+}
+
+// readListFile parses a plain-text list file
+func readListFile(path string, kind ListKind) ([]string, int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	entries, invalid := parseList(io.LimitReader(f, maxListSize), kind)
+	if err := validateListCounts(len(entries), invalid); err != nil {
+		return nil, invalid, err
+	}
+	return entries, invalid, nil
+}
+
+// parseList reads one entry per line. Blank lines and text after '#' are
+// ignored. Entries that are not valid for kind are counted and skipped.
+func parseList(r io.Reader, kind ListKind) (entries []string, invalid int) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		entry := normalizeListEntry(line)
+		if entry == "" {
+			continue
+		}
+		if !validListEntry(kind, entry) {
+			invalid++
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, invalid
+}
+
+// normalizeListEntry trims and lowercases a list entry
+func normalizeListEntry(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+// validListEntry checks that entry looks like a domain (disposable, free) or
+// a local-part token (role). Disposable entries may use '*' wildcards.
+func validListEntry(kind ListKind, entry string) bool {
+	if kind == ListRole {
+		return len(entry) <= 64 && onlyChars(entry, "abcdefghijklmnopqrstuvwxyz0123456789._-")
+	}
+	if kind == ListDisposableMX && strings.Contains(entry, "/") {
+		_, _, err := net.ParseCIDR(entry)
+		return err == nil
+	}
+	if kind == ListDisposable && strings.Contains(entry, "*") {
+		if _, err := path.Match(entry, ""); err != nil {
+			return false
+		}
+		entry = strings.ReplaceAll(entry, "*", "x")
+	}
+	if len(entry) > 253 || !strings.Contains(entry, ".") {
+		return false
+	}
+	for _, label := range strings.Split(entry, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		if !onlyChars(label, "abcdefghijklmnopqrstuvwxyz0123456789-") {
+			return false
+		}
+	}
+	return true
+}
+
+// cachePath returns where the cached copy of a URL source lives
+func cachePath(cacheDir string, kind ListKind, src string) string {
+	sum := sha256.Sum256([]byte(src))
+	return filepath.Join(cacheDir, fmt.Sprintf("%s-%s.txt", kind, hex.EncodeToString(sum[:])[:12]))
+}
+
+// readCacheMeta loads the metadata stored next to a cached list
+func readCacheMeta(path string) (*listCacheMeta, error) {
+	data, err := os.ReadFile(path + ".json")
+	if err != nil {
+		return nil, err
+	}
+	var meta listCacheMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+// fileVersion derives a version for a local list file from its content
+func fileVersion(path string) (string, time.Time) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", time.Time{}
+	}
+	sum := sha256.Sum256(data)
+	var modTime time.Time
+	if st, err := os.Stat(path); err == nil {
+		modTime = st.ModTime()
+	}
+	return hex.EncodeToString(sum[:])[:12], modTime
+}
+
+// writeFileAtomic writes data to a temp file and renames it over path so a
+// crash never leaves a half-written list behind
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func isURL(src string) bool {
+	return strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://")
+}
@@ -0,0 +1,147 @@
+package classifier
+
+import (
+	"net"
+	"strings"
+)
+
+// Values for DisposableMatch.Via
+const (
+	DisposableViaDomainList    = "domain list"
+	DisposableViaMXFingerprint = "mx fingerprint"
+)
+
+// disposableMXHosts are mail hosts (and parent domains of mail hosts) run by
+// disposable email services. Throwaway services register new domains all the
+// time but point them at the same infrastructure, so a domain whose MX lives
+// here is disposable even if the domain itself is not on any list.
+var disposableMXHosts = map[string]bool{
+	"mailinator.com":        true,
+	"guerrillamail.com":     true,
+	"yopmail.com":           true,
+	"mail.tm":               true,
+	"dropmail.me":           true,
+	"maildrop.cc":           true,
+	"mailnesia.com":         true,
+	"harakirimail.com":      true,
+	"trashmail.com":         true,
+	"getnada.com":           true,
+	"10minutemail.com":      true,
+	"emailondeck.com":       true,
+	"mailcatch.com":         true,
+	"spamgourmet.com":       true,
+	"mytemp.email":          true,
+	"tempr.email":           true,
+	"mohmal.com":            true,
+	"fakemailgenerator.com": true,
+	"throwawaymail.com":     true,
+	"tempail.com":           true,
+	"emailfake.com":         true,
+	"generator.email":       true,
+	"1secmail.com":          true,
+	"mailpoof.com":          true,
+	"burnermail.io":         true,
+	"temp-mail.org":         true,
+	"tempmail.plus":         true,
+	"discard.email":         true,
+	"spambox.us":            true,
+	"anonaddy.me":           false, // forwarding service, not disposable
+}
+
+// mxProviderPatterns maps MX host suffixes to the mailbox provider (or mail
+// gateway) operating them
+var mxProviderPatterns = []struct {
+	suffix   string
+	provider Provider
+}{
+	{".google.com", ProviderGoogleWorkspace},
+	{".googlemail.com", ProviderGoogleWorkspace},
+	{".mail.protection.outlook.com", ProviderMicrosoft365},
+	{".zoho.com", ProviderZoho},
+	{".zoho.eu", ProviderZoho},
+	{".zoho.in", ProviderZoho},
+	{".zohomail.com", ProviderZoho},
+	{".pphosted.com", ProviderProofpoint},
+	{".ppe-hosted.com", ProviderProofpoint},
+	{".mimecast.com", ProviderMimecast},
+	{".mimecast.co.za", ProviderMimecast},
+	{".mimecast-offshore.com", ProviderMimecast},
+}
+
+// detectMXProvider identifies the provider operating mxHosts. A domain whose
+// MX hosts sit under its own registrable domain is reported as self-hosted.
+func detectMXProvider(domain string, mxHosts []string) Provider {
+	for _, mx := range mxHosts {
+		mx = strings.TrimSuffix(strings.ToLower(mx), ".")
+		for _, p := range mxProviderPatterns {
+			if strings.HasSuffix(mx, p.suffix) {
+				return p.provider
+			}
+		}
+	}
+
+	if len(mxHosts) > 0 {
+		own := registrableDomain(domain)
+		for _, mx := range mxHosts {
+			mx = strings.TrimSuffix(strings.ToLower(mx), ".")
+			if own != "" && registrableDomain(mx) == own {
+				return ProviderSelfHosted
+			}
+		}
+	}
+
+	return ProviderUnknown
+}
+
+// MatchDisposableMX returns a match if any MX host belongs to a known
+// disposable mail service, or any resolved MX address falls in a disposable
+// netblock (netblocks come from loaded "disposable_mx" lists). It returns
+// nil when nothing matches.
+func MatchDisposableMX(mxHosts []string, mxIPs []net.IP) *DisposableMatch {
+	for _, mx := range mxHosts {
+		mx = strings.TrimSuffix(strings.ToLower(mx), ".")
+		if mx == "" {
+			continue
+		}
+		for _, name := range mxHostParents(mx) {
+			if allowed(ListDisposableMX, name) {
+				break
+			}
+			if ok, src := listed(ListDisposableMX, name); ok {
+				return &DisposableMatch{Entry: name, Source: src, Via: DisposableViaMXFingerprint}
+			}
+		}
+	}
+
+	for _, ip := range mxIPs {
+		if nb, ok := matchNetblock(ListDisposableMX, ip); ok {
+			return &DisposableMatch{Entry: nb.network.String(), Source: nb.source, Via: DisposableViaMXFingerprint}
+		}
+	}
+
+	return nil
+}
+
+// HasMXNetblocks returns true if any disposable MX netblocks are loaded, in
+// which case callers should resolve MX hosts to IPs for MatchDisposableMX.
+func HasMXNetblocks() bool {
+	lists.RLock()
+	defer lists.RUnlock()
+	return len(lists.netblocks[ListDisposableMX]) > 0
+}
+
+// GetDisposableMXCount returns the number of disposable MX fingerprints
+func GetDisposableMXCount() int {
+	return listCount(ListDisposableMX)
+}
+
+// mxHostParents returns host and each parent domain that still has at least
+// two labels ("mail2.mailinator.com" -> itself, "mailinator.com")
+func mxHostParents(host string) []string {
+	names := []string{host}
+	for name := host; strings.Count(name, ".") > 1; {
+		name = name[strings.IndexByte(name, '.')+1:]
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,132 @@
+package classifier
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Provider identifies the mailbox provider that hosts a domain
+type Provider string
+
+const (
+	ProviderUnknown         Provider = ""
+	ProviderGmail           Provider = "gmail"
+	ProviderGoogleWorkspace Provider = "google_workspace"
+	ProviderOutlook         Provider = "outlook"
+	ProviderMicrosoft365    Provider = "microsoft_365"
+	ProviderYahoo           Provider = "yahoo"
+	ProviderZoho            Provider = "zoho"
+	ProviderProofpoint      Provider = "proofpoint"
+	ProviderMimecast        Provider = "mimecast"
+	ProviderSelfHosted      Provider = "self_hosted"
+)
+
+// consumerProviders maps the first label of a free-mail domain to its
+// provider. Only domains that IsFreeProvider also knows are matched, so
+// e.g. "live.example.com" is never treated as Outlook.
+var consumerProviders = map[string]Provider{
+	"gmail":      ProviderGmail,
+	"googlemail": ProviderGmail,
+	"outlook":    ProviderOutlook,
+	"hotmail":    ProviderOutlook,
+	"live":       ProviderOutlook,
+	"msn":        ProviderOutlook,
+	"yahoo":      ProviderYahoo,
+	"ymail":      ProviderYahoo,
+	"rocketmail": ProviderYahoo,
+}
+
+// DetectProvider identifies the mailbox provider for domain. Consumer
+// domains are recognized by name; other domains are matched on their MX
+// hosts so that custom domains hosted on Google Workspace, Microsoft 365,
+// Zoho or behind a Proofpoint/Mimecast gateway are detected too.
+func DetectProvider(domain string, mxHosts []string) Provider {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+
+	// Yahoo Japan is a separate company with its own account rules
+	if IsFreeProvider(domain) && domain != "yahoo.co.jp" {
+		label := strings.SplitN(domain, ".", 2)[0]
+		if p, ok := consumerProviders[label]; ok {
+			return p
+		}
+	}
+
+	return detectMXProvider(domain, mxHosts)
+}
+
+// ValidateLocalPart checks localPart against the known account-name rules
+// of provider. It returns false and a reason when no mailbox with that name
+// can exist. Sub-address tags (Gmail/Outlook "+tag", Yahoo "-keyword") are
+// stripped before checking. Unknown providers always pass.
+func ValidateLocalPart(provider Provider, localPart string) (bool, string) {
+	localPart = strings.ToLower(localPart)
+
+	switch provider {
+	case ProviderGmail:
+		name := stripTag(localPart, '+')
+		if !onlyChars(name, "abcdefghijklmnopqrstuvwxyz0123456789.") {
+			return false, "Gmail usernames may only contain letters, digits and dots"
+		}
+		if n := len(strings.ReplaceAll(name, ".", "")); n < 6 || n > 30 {
+			return false, fmt.Sprintf("Gmail usernames must be 6-30 characters (got %d)", n)
+		}
+
+	case ProviderGoogleWorkspace:
+		name := stripTag(localPart, '+')
+		if !onlyChars(name, "abcdefghijklmnopqrstuvwxyz0123456789.-_'") {
+			return false, "Google Workspace usernames may only contain letters, digits, dots, dashes, underscores and apostrophes"
+		}
+
+	case ProviderOutlook:
+		name := stripTag(localPart, '+')
+		if !onlyChars(name, "abcdefghijklmnopqrstuvwxyz0123456789._-") {
+			return false, "Outlook usernames may only contain letters, digits, dots, underscores and hyphens"
+		}
+		if name == "" || name[0] < 'a' || name[0] > 'z' {
+			return false, "Outlook usernames must start with a letter"
+		}
+
+	case ProviderMicrosoft365:
+		if !onlyChars(localPart, "abcdefghijklmnopqrstuvwxyz0123456789!#$%&'*+-/=?^_`{|}~.") {
+			return false, "Microsoft 365 addresses may not contain quoted or non-ASCII characters"
+		}
+
+	case ProviderYahoo:
+		name := stripTag(localPart, '-')
+		if !onlyChars(name, "abcdefghijklmnopqrstuvwxyz0123456789._") {
+			return false, "Yahoo usernames may only contain letters, digits, underscores and one dot"
+		}
+		if n := len(name); n < 4 || n > 32 {
+			return false, fmt.Sprintf("Yahoo usernames must be 4-32 characters (got %d)", n)
+		}
+		if name[0] < 'a' || name[0] > 'z' {
+			return false, "Yahoo usernames must start with a letter"
+		}
+		if strings.Count(name, ".") > 1 {
+			return false, "Yahoo usernames may contain at most one dot"
+		}
+		if last := name[len(name)-1]; last == '.' || last == '_' {
+			return false, "Yahoo usernames cannot end with a dot or underscore"
+		}
+	}
+
+	return true, ""
+}
+
+// stripTag removes a sub-address tag introduced by sep
+func stripTag(localPart string, sep byte) string {
+	if i := strings.IndexByte(localPart, sep); i >= 0 {
+		return localPart[:i]
+	}
+	return localPart
+}
+
+// onlyChars returns true if every byte of s is in allowed
+func onlyChars(s, allowed string) bool {
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(allowed, s[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -1,205 +1,439 @@
 package classifier
 
 import (
+	"fmt"
+	"sort"
 	"strings"
 )
 
-// Role-based email prefixes
-var rolePrefixes = map[string]bool{
-	// Administrative
-	"admin":         true,
-	"administrator": true,
-	"postmaster":    true,
-	"hostmaster":    true,
-	"webmaster":     true,
-	"root":          true,
-	"sysadmin":      true,
-
-	// Support
-	"support":       true,
-	"help":          true,
-	"helpdesk":      true,
-	"customerservice": true,
-	"service":       true,
-	"tech":          true,
-	"technical":     true,
-
-	// Contact/Info
-	"info":          true,
-	"information":   true,
-	"contact":       true,
-	"contactus":     true,
-	"hello":         true,
-	"hi":            true,
-	"enquiry":       true,
-	"enquiries":     true,
-	"inquiry":       true,
-	"feedback":      true,
-
-	// Sales/Marketing
-	"sales":         true,
-	"marketing":     true,
-	"press":         true,
-	"media":         true,
-	"pr":            true,
-	"advertising":   true,
-	"ads":           true,
-	"partnerships":  true,
-	"partner":       true,
-	"business":      true,
-	"biz":           true,
-
-	// No-reply
-	"noreply":       true,
-	"no-reply":      true,
-	"donotreply":    true,
-	"do-not-reply":  true,
-	"mailer-daemon": true,
-	"mailerdaemon":  true,
-	"daemon":        true,
-	"bounce":        true,
-	"bounces":       true,
-
-	// Security/Abuse
-	"abuse":         true,
-	"security":      true,
-	"spam":          true,
-	"phishing":      true,
-	"fraud":         true,
-	"compliance":    true,
-	"legal":         true,
-	"privacy":       true,
-	"dmca":          true,
-
-	// Finance
-	"billing":       true,
-	"invoice":       true,
-	"invoices":      true,
-	"accounting":    true,
-	"accounts":      true,
-	"finance":       true,
-	"payments":      true,
-	"payroll":       true,
-
-	// HR/Jobs
-	"hr":            true,
-	"humanresources": true,
-	"recruiting":    true,
-	"recruitment":   true,
-	"jobs":          true,
-	"careers":       true,
-	"career":        true,
-	"talent":        true,
-	"resume":        true,
-	"resumes":       true,
-	"cv":            true,
-
-	// Team/Department
-	"team":          true,
-	"staff":         true,
-	"office":        true,
-	"reception":     true,
-	"all":           true,
-	"everyone":      true,
-	"company":       true,
-	"group":         true,
-	"dept":          true,
-	"department":    true,
-
-	// IT/Dev
-	"it":            true,
-	"dev":           true,
-	"developer":     true,
-	"developers":    true,
-	"development":   true,
-	"engineering":   true,
-	"devops":        true,
-	"ops":           true,
-	"operations":    true,
-	"network":       true,
-	"sysops":        true,
-	"noc":           true,
-
-	// Orders/Shopping
-	"orders":        true,
-	"order":         true,
-	"shop":          true,
-	"store":         true,
-	"checkout":      true,
-	"shipping":      true,
-	"delivery":      true,
-	"returns":       true,
-	"refund":        true,
-	"refunds":       true,
-	"fulfillment":   true,
-
-	// Newsletters/Lists
-	"news":          true,
-	"newsletter":    true,
-	"newsletters":   true,
-	"updates":       true,
-	"subscribe":     true,
-	"subscriptions": true,
-	"unsubscribe":   true,
-	"list":          true,
-	"lists":         true,
-	"announce":      true,
-	"announcements": true,
-	"notifications": true,
-	"alerts":        true,
-
-	// Social
-	"social":        true,
-	"community":     true,
-	"forum":         true,
-	"blog":          true,
-
-	// Misc
-	"test":          true,
-	"testing":       true,
-	"demo":          true,
-	"example":       true,
-	"sample":        true,
-	"null":          true,
-	"void":          true,
-	"nobody":        true,
-	"www":           true,
-	"ftp":           true,
-	"mail":          true,
-	"email":         true,
+// RoleCategory groups role accounts by the function they serve
+type RoleCategory string
+
+const (
+	RoleCategoryNone      RoleCategory = ""
+	RoleCategoryAdmin     RoleCategory = "admin"
+	RoleCategorySupport   RoleCategory = "support"
+	RoleCategorySales     RoleCategory = "sales"
+	RoleCategoryMarketing RoleCategory = "marketing"
+	RoleCategoryInfo      RoleCategory = "info"
+	RoleCategoryNoReply   RoleCategory = "noreply"
+	RoleCategorySecurity  RoleCategory = "security"
+	RoleCategoryBilling   RoleCategory = "billing"
+	RoleCategoryHR        RoleCategory = "hr"
+	RoleCategoryTechnical RoleCategory = "technical"
+	RoleCategoryTeam      RoleCategory = "team"
+	RoleCategoryOther     RoleCategory = "other"
+)
+
+// RoleConfidence is how sure the detector is that a local part is a role
+// account rather than a person
+type RoleConfidence int
+
+const (
+	RoleConfidenceNone RoleConfidence = iota
+	RoleConfidenceLow
+	RoleConfidenceMedium
+	RoleConfidenceHigh
+)
+
+// String returns the flag name of the confidence level
+func (c RoleConfidence) String() string {
+	switch c {
+	case RoleConfidenceLow:
+		return "low"
+	case RoleConfidenceMedium:
+		return "medium"
+	case RoleConfidenceHigh:
+		return "high"
+	default:
+		return "none"
+	}
+}
+
+// ParseRoleConfidence converts a flag value (low, medium, high) to a level
+func ParseRoleConfidence(s string) (RoleConfidence, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "low":
+		return RoleConfidenceLow, nil
+	case "", "medium":
+		return RoleConfidenceMedium, nil
+	case "high":
+		return RoleConfidenceHigh, nil
+	default:
+		return RoleConfidenceMedium, fmt.Errorf("unknown role confidence %q (use low, medium or high)", s)
+	}
+}
+
+// roleStrength says how strongly a term on its own implies a role account
+type roleStrength int
+
+const (
+	// roleWeak terms double as names, initials or ordinary words ("hi",
+	// "pr", "mail", "tech") and only count when nothing else is present
+	roleWeak roleStrength = iota + 1
+	// roleStrong terms are almost always functions ("support", "sales")
+	roleStrong
+	// roleDefinitive terms are never people ("noreply", "postmaster")
+	roleDefinitive
+)
+
+// roleTerm describes one vocabulary entry
+type roleTerm struct {
+	category RoleCategory
+	strength roleStrength
+	lang     string
+}
+
+// roleVocabulary holds role terms per language. Terms are written without
+// separators; local parts are matched after splitting on separators, so
+// "no-reply", "no.reply" and "noreply" all match "noreply".
+var roleVocabulary = map[string]map[string]roleTerm{
+	"en": {
+		// Administrative
+		"admin": {RoleCategoryAdmin, roleStrong, ""}, "administrator": {RoleCategoryAdmin, roleStrong, ""},
+		"postmaster": {RoleCategoryAdmin, roleDefinitive, ""}, "hostmaster": {RoleCategoryAdmin, roleDefinitive, ""},
+		"webmaster": {RoleCategoryAdmin, roleDefinitive, ""}, "root": {RoleCategoryAdmin, roleStrong, ""},
+		"sysadmin": {RoleCategoryAdmin, roleStrong, ""}, "office": {RoleCategoryAdmin, roleWeak, ""},
+		"reception": {RoleCategoryAdmin, roleStrong, ""},
+
+		// Support
+		"support": {RoleCategorySupport, roleStrong, ""}, "help": {RoleCategorySupport, roleStrong, ""},
+		"helpdesk": {RoleCategorySupport, roleStrong, ""}, "customerservice": {RoleCategorySupport, roleStrong, ""},
+		"customercare": {RoleCategorySupport, roleStrong, ""}, "customersupport": {RoleCategorySupport, roleStrong, ""},
+		"service": {RoleCategorySupport, roleWeak, ""}, "feedback": {RoleCategorySupport, roleStrong, ""},
+
+		// Contact/Info
+		"info": {RoleCategoryInfo, roleStrong, ""}, "information": {RoleCategoryInfo, roleWeak, ""},
+		"contact": {RoleCategoryInfo, roleStrong, ""}, "contactus": {RoleCategoryInfo, roleStrong, ""},
+		"hello": {RoleCategoryInfo, roleWeak, ""}, "hi": {RoleCategoryInfo, roleWeak, ""},
+		"enquiry": {RoleCategoryInfo, roleStrong, ""}, "enquiries": {RoleCategoryInfo, roleStrong, ""},
+		"inquiry": {RoleCategoryInfo, roleStrong, ""}, "inquiries": {RoleCategoryInfo, roleStrong, ""},
+		"mail": {RoleCategoryInfo, roleWeak, ""}, "email": {RoleCategoryInfo, roleWeak, ""},
+		"www": {RoleCategoryInfo, roleWeak, ""}, "ftp": {RoleCategoryTechnical, roleWeak, ""},
+
+		// Sales/Marketing
+		"sales": {RoleCategorySales, roleStrong, ""}, "business": {RoleCategorySales, roleWeak, ""},
+		"biz": {RoleCategorySales, roleWeak, ""}, "partner": {RoleCategorySales, roleWeak, ""},
+		"partners": {RoleCategorySales, roleStrong, ""}, "partnerships": {RoleCategorySales, roleStrong, ""},
+		"orders": {RoleCategorySales, roleStrong, ""}, "order": {RoleCategorySales, roleWeak, ""},
+		"shop": {RoleCategorySales, roleWeak, ""}, "store": {RoleCategorySales, roleWeak, ""},
+		"checkout": {RoleCategorySales, roleStrong, ""}, "shipping": {RoleCategorySales, roleStrong, ""},
+		"delivery": {RoleCategorySales, roleWeak, ""}, "returns": {RoleCategorySales, roleStrong, ""},
+		"refund": {RoleCategorySales, roleStrong, ""}, "refunds": {RoleCategorySales, roleStrong, ""},
+		"fulfillment": {RoleCategorySales, roleStrong, ""},
+
+		"marketing": {RoleCategoryMarketing, roleStrong, ""}, "press": {RoleCategoryMarketing, roleStrong, ""},
+		"media": {RoleCategoryMarketing, roleWeak, ""}, "pr": {RoleCategoryMarketing, roleWeak, ""},
+		"advertising": {RoleCategoryMarketing, roleStrong, ""}, "ads": {RoleCategoryMarketing, roleWeak, ""},
+		"news": {RoleCategoryMarketing, roleWeak, ""}, "newsletter": {RoleCategoryMarketing, roleStrong, ""},
+		"newsletters": {RoleCategoryMarketing, roleStrong, ""}, "updates": {RoleCategoryMarketing, roleWeak, ""},
+		"subscribe": {RoleCategoryMarketing, roleStrong, ""}, "subscriptions": {RoleCategoryMarketing, roleStrong, ""},
+		"unsubscribe": {RoleCategoryMarketing, roleDefinitive, ""}, "list": {RoleCategoryMarketing, roleWeak, ""},
+		"lists": {RoleCategoryMarketing, roleWeak, ""}, "announce": {RoleCategoryMarketing, roleStrong, ""},
+		"announcements": {RoleCategoryMarketing, roleStrong, ""}, "social": {RoleCategoryMarketing, roleWeak, ""},
+		"community": {RoleCategoryMarketing, roleWeak, ""}, "forum": {RoleCategoryMarketing, roleWeak, ""},
+		"blog": {RoleCategoryMarketing, roleWeak, ""},
+
+		// No-reply and automated senders
+		"noreply": {RoleCategoryNoReply, roleDefinitive, ""}, "donotreply": {RoleCategoryNoReply, roleDefinitive, ""},
+		"mailerdaemon": {RoleCategoryNoReply, roleDefinitive, ""}, "daemon": {RoleCategoryNoReply, roleStrong, ""},
+		"bounce": {RoleCategoryNoReply, roleDefinitive, ""}, "bounces": {RoleCategoryNoReply, roleDefinitive, ""},
+		"notifications": {RoleCategoryNoReply, roleStrong, ""}, "notification": {RoleCategoryNoReply, roleStrong, ""},
+		"alerts": {RoleCategoryNoReply, roleStrong, ""}, "nobody": {RoleCategoryNoReply, roleStrong, ""},
+		"null": {RoleCategoryNoReply, roleWeak, ""}, "void": {RoleCategoryNoReply, roleWeak, ""},
+
+		// Security/Legal
+		"abuse": {RoleCategorySecurity, roleDefinitive, ""}, "security": {RoleCategorySecurity, roleStrong, ""},
+		"spam": {RoleCategorySecurity, roleStrong, ""}, "phishing": {RoleCategorySecurity, roleStrong, ""},
+		"fraud": {RoleCategorySecurity, roleStrong, ""}, "compliance": {RoleCategorySecurity, roleStrong, ""},
+		"legal": {RoleCategorySecurity, roleStrong, ""}, "privacy": {RoleCategorySecurity, roleStrong, ""},
+		"dmca": {RoleCategorySecurity, roleDefinitive, ""},
+
+		// Finance
+		"billing": {RoleCategoryBilling, roleStrong, ""}, "invoice": {RoleCategoryBilling, roleStrong, ""},
+		"invoices": {RoleCategoryBilling, roleStrong, ""}, "accounting": {RoleCategoryBilling, roleStrong, ""},
+		"accounts": {RoleCategoryBilling, roleStrong, ""}, "finance": {RoleCategoryBilling, roleStrong, ""},
+		"payments": {RoleCategoryBilling, roleStrong, ""}, "payroll": {RoleCategoryBilling, roleStrong, ""},
+
+		// HR/Jobs
+		"hr": {RoleCategoryHR, roleWeak, ""}, "humanresources": {RoleCategoryHR, roleStrong, ""},
+		"recruiting": {RoleCategoryHR, roleStrong, ""}, "recruitment": {RoleCategoryHR, roleStrong, ""},
+		"jobs": {RoleCategoryHR, roleStrong, ""}, "careers": {RoleCategoryHR, roleStrong, ""},
+		"career": {RoleCategoryHR, roleWeak, ""}, "talent": {RoleCategoryHR, roleWeak, ""},
+		"resume": {RoleCategoryHR, roleStrong, ""}, "resumes": {RoleCategoryHR, roleStrong, ""},
+		"cv": {RoleCategoryHR, roleWeak, ""},
+
+		// Team/Department
+		"team": {RoleCategoryTeam, roleWeak, ""}, "staff": {RoleCategoryTeam, roleWeak, ""},
+		"all": {RoleCategoryTeam, roleWeak, ""}, "everyone": {RoleCategoryTeam, roleStrong, ""},
+		"company": {RoleCategoryTeam, roleWeak, ""}, "group": {RoleCategoryTeam, roleWeak, ""},
+		"dept": {RoleCategoryTeam, roleWeak, ""}, "department": {RoleCategoryTeam, roleWeak, ""},
+
+		// IT/Dev
+		"it": {RoleCategoryTechnical, roleWeak, ""}, "tech": {RoleCategoryTechnical, roleWeak, ""},
+		"technical": {RoleCategoryTechnical, roleWeak, ""}, "dev": {RoleCategoryTechnical, roleWeak, ""},
+		"developer": {RoleCategoryTechnical, roleWeak, ""}, "developers": {RoleCategoryTechnical, roleStrong, ""},
+		"development": {RoleCategoryTechnical, roleWeak, ""}, "engineering": {RoleCategoryTechnical, roleWeak, ""},
+		"devops": {RoleCategoryTechnical, roleStrong, ""}, "ops": {RoleCategoryTechnical, roleWeak, ""},
+		"operations": {RoleCategoryTechnical, roleWeak, ""}, "network": {RoleCategoryTechnical, roleWeak, ""},
+		"sysops": {RoleCategoryTechnical, roleStrong, ""}, "noc": {RoleCategoryTechnical, roleStrong, ""},
+
+		// Misc
+		"test": {RoleCategoryOther, roleWeak, ""}, "testing": {RoleCategoryOther, roleWeak, ""},
+		"demo": {RoleCategoryOther, roleWeak, ""}, "example": {RoleCategoryOther, roleWeak, ""},
+		"sample": {RoleCategoryOther, roleWeak, ""},
+	},
+	"de": {
+		"kontakt": {RoleCategoryInfo, roleStrong, ""}, "anfrage": {RoleCategoryInfo, roleStrong, ""},
+		"anfragen": {RoleCategoryInfo, roleStrong, ""}, "impressum": {RoleCategoryInfo, roleStrong, ""},
+		"kundenservice": {RoleCategorySupport, roleStrong, ""}, "kundendienst": {RoleCategorySupport, roleStrong, ""},
+		"hilfe": {RoleCategorySupport, roleStrong, ""}, "vertrieb": {RoleCategorySales, roleStrong, ""},
+		"verkauf": {RoleCategorySales, roleStrong, ""}, "bestellung": {RoleCategorySales, roleStrong, ""},
+		"presse": {RoleCategoryMarketing, roleStrong, ""}, "buchhaltung": {RoleCategoryBilling, roleStrong, ""},
+		"rechnung": {RoleCategoryBilling, roleStrong, ""}, "rechnungen": {RoleCategoryBilling, roleStrong, ""},
+		"verwaltung": {RoleCategoryAdmin, roleStrong, ""}, "sekretariat": {RoleCategoryAdmin, roleStrong, ""},
+		"zentrale": {RoleCategoryAdmin, roleStrong, ""}, "bewerbung": {RoleCategoryHR, roleStrong, ""},
+		"bewerbungen": {RoleCategoryHR, roleStrong, ""}, "karriere": {RoleCategoryHR, roleStrong, ""},
+		"datenschutz": {RoleCategorySecurity, roleStrong, ""}, "keineantwort": {RoleCategoryNoReply, roleDefinitive, ""},
+	},
+	"es": {
+		"contacto": {RoleCategoryInfo, roleStrong, ""}, "informacion": {RoleCategoryInfo, roleStrong, ""},
+		"soporte": {RoleCategorySupport, roleStrong, ""}, "ayuda": {RoleCategorySupport, roleStrong, ""},
+		"atencion": {RoleCategorySupport, roleWeak, ""}, "ventas": {RoleCategorySales, roleStrong, ""},
+		"pedidos": {RoleCategorySales, roleStrong, ""}, "tienda": {RoleCategorySales, roleWeak, ""},
+		"prensa": {RoleCategoryMarketing, roleStrong, ""}, "facturacion": {RoleCategoryBilling, roleStrong, ""},
+		"facturas": {RoleCategoryBilling, roleStrong, ""}, "contabilidad": {RoleCategoryBilling, roleStrong, ""},
+		"administracion": {RoleCategoryAdmin, roleStrong, ""}, "empleo": {RoleCategoryHR, roleStrong, ""},
+		"rrhh": {RoleCategoryHR, roleStrong, ""}, "noresponder": {RoleCategoryNoReply, roleDefinitive, ""},
+	},
+	"fr": {
+		"accueil": {RoleCategoryInfo, roleStrong, ""}, "renseignements": {RoleCategoryInfo, roleStrong, ""},
+		"assistance": {RoleCategorySupport, roleStrong, ""}, "aide": {RoleCategorySupport, roleStrong, ""},
+		"sav": {RoleCategorySupport, roleStrong, ""}, "ventes": {RoleCategorySales, roleStrong, ""},
+		"commercial": {RoleCategorySales, roleStrong, ""}, "commandes": {RoleCategorySales, roleStrong, ""},
+		"boutique": {RoleCategorySales, roleWeak, ""}, "presse": {RoleCategoryMarketing, roleStrong, ""},
+		"compta": {RoleCategoryBilling, roleStrong, ""}, "comptabilite": {RoleCategoryBilling, roleStrong, ""},
+		"facturation": {RoleCategoryBilling, roleStrong, ""}, "factures": {RoleCategoryBilling, roleStrong, ""},
+		"secretariat": {RoleCategoryAdmin, roleStrong, ""}, "direction": {RoleCategoryAdmin, roleWeak, ""},
+		"recrutement": {RoleCategoryHR, roleStrong, ""}, "emploi": {RoleCategoryHR, roleStrong, ""},
+		"rh": {RoleCategoryHR, roleWeak, ""}, "nepasrepondre": {RoleCategoryNoReply, roleDefinitive, ""},
+	},
+	"it": {
+		"contatti": {RoleCategoryInfo, roleStrong, ""}, "informazioni": {RoleCategoryInfo, roleStrong, ""},
+		"assistenza": {RoleCategorySupport, roleStrong, ""}, "vendite": {RoleCategorySales, roleStrong, ""},
+		"ordini": {RoleCategorySales, roleStrong, ""}, "amministrazione": {RoleCategoryAdmin, roleStrong, ""},
+		"segreteria": {RoleCategoryAdmin, roleStrong, ""}, "ufficio": {RoleCategoryAdmin, roleWeak, ""},
+		"fatturazione": {RoleCategoryBilling, roleStrong, ""}, "fatture": {RoleCategoryBilling, roleStrong, ""},
+		"contabilita": {RoleCategoryBilling, roleStrong, ""}, "lavoro": {RoleCategoryHR, roleWeak, ""},
+		"stampa": {RoleCategoryMarketing, roleWeak, ""}, "nonrispondere": {RoleCategoryNoReply, roleDefinitive, ""},
+	},
+	"pt": {
+		"contato": {RoleCategoryInfo, roleStrong, ""}, "contacto": {RoleCategoryInfo, roleStrong, ""},
+		"suporte": {RoleCategorySupport, roleStrong, ""}, "atendimento": {RoleCategorySupport, roleStrong, ""},
+		"vendas": {RoleCategorySales, roleStrong, ""}, "comercial": {RoleCategorySales, roleStrong, ""},
+		"pedidos": {RoleCategorySales, roleStrong, ""}, "financeiro": {RoleCategoryBilling, roleStrong, ""},
+		"faturamento": {RoleCategoryBilling, roleStrong, ""}, "imprensa": {RoleCategoryMarketing, roleStrong, ""},
+		"vagas": {RoleCategoryHR, roleStrong, ""}, "naoresponda": {RoleCategoryNoReply, roleDefinitive, ""},
+	},
+	"nl": {
+		"klantenservice": {RoleCategorySupport, roleStrong, ""}, "verkoop": {RoleCategorySales, roleStrong, ""},
+		"bestellingen": {RoleCategorySales, roleStrong, ""}, "administratie": {RoleCategoryAdmin, roleStrong, ""},
+		"boekhouding": {RoleCategoryBilling, roleStrong, ""}, "facturen": {RoleCategoryBilling, roleStrong, ""},
+		"vacatures": {RoleCategoryHR, roleStrong, ""}, "pers": {RoleCategoryMarketing, roleWeak, ""},
+		"geenantwoord": {RoleCategoryNoReply, roleDefinitive, ""},
+	},
+}
+
+// roleTerms is roleVocabulary flattened into a single lookup with the
+// language filled in. English wins when languages share a term.
+var roleTerms = flattenRoleVocabulary()
+
+// rolePrefixes is the built-in role list as seen by the list registry
+var rolePrefixes = func() map[string]bool {
+	set := make(map[string]bool, len(roleTerms))
+	for term := range roleTerms {
+		set[term] = true
+	}
+	return set
+}()
+
+// flattenRoleVocabulary visits languages in sorted order so that a term
+// shared by several non-English languages always gets the same one
+func flattenRoleVocabulary() map[string]roleTerm {
+	langs := make([]string, 0, len(roleVocabulary))
+	for lang := range roleVocabulary {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+
+	terms := make(map[string]roleTerm)
+	for _, lang := range langs {
+		for word, t := range roleVocabulary[lang] {
+			if existing, ok := terms[word]; ok && (existing.lang == "en" || lang != "en") {
+				continue
+			}
+			t.lang = lang
+			terms[word] = t
+		}
+	}
+	return terms
 }
 
-// IsRoleAccount checks if the local part indicates a role account
+// RoleMatch describes why a local part was recognized as a role account
+type RoleMatch struct {
+	Term       string         `json:"term,omitempty"`
+	Category   RoleCategory   `json:"category,omitempty"`
+	Confidence RoleConfidence `json:"-"`
+	Language   string         `json:"language,omitempty"`
+	Source     string         `json:"source,omitempty"` // "builtin", a file path or a URL
+}
+
+// IsRoleAccount checks if the local part indicates a role account with at
+// least medium confidence
 func IsRoleAccount(localPart string) bool {
+	return DetectRole(localPart).Confidence >= RoleConfidenceMedium
+}
+
+// DetectRole decides whether localPart names a function rather than a
+// person. The local part is split into tokens on separators and digits
+// ("sales.team2" -> sales, team), and tokens are looked up in the role
+// vocabulary. Ambiguous terms ("hi", "pr", "mail", "tech") only count when
+// every token is a role term, so "hi.nguyen" or "tech_lead" are people while
+// "hi" or "it.support" are roles:
+//   - high:   a never-a-person term (noreply, postmaster), or only role terms
+//     including at least one unambiguous one
+//   - medium: only ambiguous role terms, or an unambiguous term next to
+//     unknown tokens ("support.emea")
+//   - low:    an ambiguous term next to unknown tokens ("hi.nguyen")
+func DetectRole(localPart string) RoleMatch {
 	localPart = strings.ToLower(strings.TrimSpace(localPart))
+	if allowed(ListRole, localPart) {
+		return RoleMatch{}
+	}
+
+	tokens := roleTokens(stripTag(localPart, '+'))
+	if len(tokens) == 0 {
+		return RoleMatch{}
+	}
+
+	// Multi-token spellings of a single term: "no-reply", "mailer.daemon"
+	if len(tokens) > 1 {
+		if m, ok := lookupRoleTerm(strings.Join(tokens, "")); ok {
+			m.Confidence = roleConfidence(m, true)
+			return m
+		}
+	}
+
+	var best RoleMatch
+	var bestStrength roleStrength
+	allRole := true
+	for _, token := range tokens {
+		m, ok := lookupRoleTerm(token)
+		if !ok {
+			m, ok = segmentRoleTerm(token)
+		}
+		if !ok {
+			allRole = false
+			continue
+		}
+		if s := termStrength(m); s > bestStrength {
+			best, bestStrength = m, s
+		}
+	}
+	if bestStrength == 0 {
+		return RoleMatch{}
+	}
 
-	// Direct match
-	if rolePrefixes[localPart] {
-		return true
+	best.Confidence = roleConfidence(best, allRole)
+	return best
+}
+
+// roleConfidence grades a match on its strongest term
+func roleConfidence(m RoleMatch, allRole bool) RoleConfidence {
+	switch s := termStrength(m); {
+	case s == roleDefinitive:
+		return RoleConfidenceHigh
+	case allRole && s == roleStrong:
+		return RoleConfidenceHigh
+	case allRole:
+		return RoleConfidenceMedium
+	case s == roleStrong:
+		return RoleConfidenceMedium
+	default:
+		return RoleConfidenceLow
+	}
+}
+
+// termStrength returns the strength of a matched term; entries from loaded
+// lists count as unambiguous
+func termStrength(m RoleMatch) roleStrength {
+	if t, ok := roleTerms[m.Term]; ok && m.Source == "builtin" {
+		return t.strength
+	}
+	return roleStrong
+}
+
+// lookupRoleTerm finds term in the built-in vocabulary or loaded role lists
+func lookupRoleTerm(term string) (RoleMatch, bool) {
+	if allowed(ListRole, term) {
+		return RoleMatch{}, false
+	}
+	if t, ok := roleTerms[term]; ok {
+		return RoleMatch{Term: term, Category: t.category, Language: t.lang, Source: "builtin"}, true
 	}
+	if src, ok := loadedRoleKey(term); ok {
+		return RoleMatch{Term: term, Category: RoleCategoryOther, Source: src}, true
+	}
+	return RoleMatch{}, false
+}
 
-	// Check if starts with role prefix followed by number or separator
-	for prefix := range rolePrefixes {
-		if strings.HasPrefix(localPart, prefix) {
-			rest := strings.TrimPrefix(localPart, prefix)
-			if rest == "" {
-				return true
+// segmentRoleTerm matches tokens that are several role terms written
+// together ("salesteam", "customersupport"). Every piece must be a term of
+// at least three letters so that names are not split into initials.
+func segmentRoleTerm(token string) (RoleMatch, bool) {
+	// reach[i] holds the strongest match covering token[:i]
+	type step struct {
+		ok    bool
+		match RoleMatch
+	}
+	reach := make([]step, len(token)+1)
+	reach[0].ok = true
+	for end := 3; end <= len(token); end++ {
+		for start := 0; start+3 <= end; start++ {
+			if !reach[start].ok {
+				continue
+			}
+			m, ok := lookupRoleTerm(token[start:end])
+			if !ok {
+				continue
+			}
+			if start > 0 && termStrength(reach[start].match) > termStrength(m) {
+				m = reach[start].match
 			}
-			// Check for common separators or numbers
-			if len(rest) > 0 {
-				firstChar := rest[0]
-				if firstChar == '-' || firstChar == '_' || firstChar == '.' ||
-				   (firstChar >= '0' && firstChar <= '9') {
-					return true
-				}
+			if !reach[end].ok || termStrength(m) > termStrength(reach[end].match) {
+				reach[end] = step{ok: true, match: m}
 			}
 		}
 	}
+	if !reach[len(token)].ok || len(token) == 0 {
+		return RoleMatch{}, false
+	}
+	return reach[len(token)].match, true
+}
+
+// roleTokens splits a local part into lowercase letter runs
+func roleTokens(localPart string) []string {
+	return strings.FieldsFunc(localPart, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z') && r < 0x80
+	})
+}
 
-	return false
+// roleKey strips separators and digits from a list entry
+func roleKey(entry string) string {
+	return strings.Join(roleTokens(strings.ToLower(entry)), "")
 }
 
-// GetRolePrefixCount returns the number of role prefixes
+// GetRolePrefixCount returns the number of role terms (built-in plus loaded)
 func GetRolePrefixCount() int {
-	return len(rolePrefixes)
+	return listCount(ListRole)
 }
@@ -0,0 +1,136 @@
+package classifier
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Rule describes a classifier defined in configuration. Every condition that
+// is set must match (a list matches if any of its entries does); the rule
+// then adds Tag, or Name if Tag is empty.
+type Rule struct {
+	Name string `mapstructure:"name" json:"name"`
+	Tag  string `mapstructure:"tag" json:"tag,omitempty"`
+	// LocalPart is a regular expression matched against the local part
+	LocalPart string `mapstructure:"local_part" json:"local_part,omitempty"`
+	// DomainSuffix matches the domain itself or any subdomain of an entry
+	DomainSuffix []string `mapstructure:"domain_suffix" json:"domain_suffix,omitempty"`
+	// MX holds wildcard patterns (e.g. "*.pphosted.com") matched against
+	// every MX host
+	MX []string `mapstructure:"mx" json:"mx,omitempty"`
+	// TLD matches the last label of the domain
+	TLD []string `mapstructure:"tld" json:"tld,omitempty"`
+}
+
+// RuleClassifier is a Classifier built from a Rule
+type RuleClassifier struct {
+	rule      Rule
+	localPart *regexp.Regexp
+}
+
+// NewRuleClassifier validates rule and compiles it into a classifier
+func NewRuleClassifier(rule Rule) (*RuleClassifier, error) {
+	if rule.Name == "" {
+		return nil, fmt.Errorf("classifier rule has no name")
+	}
+	if rule.LocalPart == "" && len(rule.DomainSuffix) == 0 && len(rule.MX) == 0 && len(rule.TLD) == 0 {
+		return nil, fmt.Errorf("classifier rule %q has no conditions", rule.Name)
+	}
+
+	c := &RuleClassifier{rule: rule}
+	if rule.LocalPart != "" {
+		re, err := regexp.Compile(rule.LocalPart)
+		if err != nil {
+			return nil, fmt.Errorf("classifier rule %q: invalid local_part pattern: %w", rule.Name, err)
+		}
+		c.localPart = re
+	}
+	for _, p := range rule.MX {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("classifier rule %q: invalid mx pattern %q", rule.Name, p)
+		}
+	}
+
+	c.rule.DomainSuffix = lowerAll(rule.DomainSuffix, ".")
+	c.rule.MX = lowerAll(rule.MX, "")
+	c.rule.TLD = lowerAll(rule.TLD, ".")
+	return c, nil
+}
+
+// Name implements Classifier
+func (c *RuleClassifier) Name() string {
+	return c.rule.Name
+}
+
+// Classify implements Classifier
+func (c *RuleClassifier) Classify(in *Input) []string {
+	domain := strings.ToLower(in.Domain)
+
+	if c.localPart != nil && !c.localPart.MatchString(in.LocalPart) {
+		return nil
+	}
+	if len(c.rule.DomainSuffix) > 0 && !matchAny(c.rule.DomainSuffix, func(s string) bool {
+		return domain == s || strings.HasSuffix(domain, "."+s)
+	}) {
+		return nil
+	}
+	if len(c.rule.TLD) > 0 {
+		tld := domain[strings.LastIndexByte(domain, '.')+1:]
+		if !matchAny(c.rule.TLD, func(s string) bool { return tld == s }) {
+			return nil
+		}
+	}
+	if len(c.rule.MX) > 0 && !matchAny(c.rule.MX, func(p string) bool {
+		for _, mx := range in.MXHosts {
+			mx = strings.TrimSuffix(strings.ToLower(mx), ".")
+			if ok, _ := path.Match(p, mx); ok {
+				return true
+			}
+		}
+		return false
+	}) {
+		return nil
+	}
+
+	if c.rule.Tag != "" {
+		return []string{c.rule.Tag}
+	}
+	return []string{c.rule.Name}
+}
+
+// RegisterRules compiles rules and registers them. Nothing is registered if
+// any rule is invalid.
+func RegisterRules(rules []Rule) error {
+	compiled := make([]*RuleClassifier, 0, len(rules))
+	for _, rule := range rules {
+		c, err := NewRuleClassifier(rule)
+		if err != nil {
+			return err
+		}
+		compiled = append(compiled, c)
+	}
+	for _, c := range compiled {
+		Register(c)
+	}
+	return nil
+}
+
+func matchAny(entries []string, match func(string) bool) bool {
+	for _, e := range entries {
+		if match(e) {
+			return true
+		}
+	}
+	return false
+}
+
+// lowerAll lowercases entries and trims leading cut characters
+func lowerAll(entries []string, cut string) []string {
+	out := make([]string, len(entries))
+	for i, e := range entries {
+		out[i] = strings.TrimLeft(strings.ToLower(strings.TrimSpace(e)), cut)
+	}
+	return out
+}
@@ -0,0 +1,338 @@
+package extract
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"net/mail"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Kind is the type of document addresses are extracted from
+type Kind string
+
+const (
+	KindText Kind = "text"
+	KindHTML Kind = "html"
+	KindEML  Kind = "eml"
+	KindMbox Kind = "mbox"
+)
+
+// Sources describe where an address was found
+const (
+	SourceText       = "text"
+	SourceMailto     = "mailto"
+	SourceObfuscated = "obfuscated"
+	// Message headers are reported as "header:<name>", e.g. header:from
+	sourceHeaderPrefix = "header:"
+)
+
+// messageHeaders are the message headers scanned for addresses
+var messageHeaders = []string{"From", "Sender", "Reply-To", "To", "Cc", "Bcc"}
+
+// skipDomainSuffixes filters file names that look like addresses, such as
+// retina images (logo@2x.png)
+var skipDomainSuffixes = []string{
+	".png", ".jpg", ".jpeg", ".gif", ".svg", ".webp", ".ico", ".css", ".js",
+}
+
+var (
+	emailPattern  = regexp.MustCompile(`[\p{L}\p{N}._%+\-'!#$&*=?^{|}~]+@[\p{L}\p{N}\-]+(?:\.[\p{L}\p{N}\-]+)*\.\p{L}{2,}`)
+	mailtoPattern = regexp.MustCompile(`(?i)mailto:([^"'<>\s]+)`)
+	tagPattern    = regexp.MustCompile(`(?s)<!--.*?-->|<(?:script|style)\b.*?</(?:script|style)>|<[^>]*>`)
+
+	// name [at] domain [dot] com, name (at) domain (dot) com, name{at}domain...
+	bracketAtPattern  = regexp.MustCompile(`(?i)\s*[\[\(\{<]\s*(?:at|@)\s*[\]\)\}>]\s*`)
+	bracketDotPattern = regexp.MustCompile(`(?i)\s*[\[\(\{<]\s*(?:dot|\.)\s*[\]\)\}>]\s*`)
+	// name at domain dot com (spelled out, needs at least one "dot")
+	spelledPattern = regexp.MustCompile(`(?i)\b([\p{L}\p{N}._%+\-]+)\s+at\s+([\p{L}\p{N}\-]+(?:\s+dot\s+[\p{L}\p{N}\-]+)+)\b`)
+	spelledDot     = regexp.MustCompile(`(?i)\s+dot\s+`)
+)
+
+// ParseKind validates a document kind given on the command line. An empty
+// name or "auto" selects detection.
+func ParseKind(name string) (Kind, error) {
+	switch k := Kind(strings.ToLower(name)); k {
+	case "", "auto":
+		return "", nil
+	case KindText, KindHTML, KindEML, KindMbox:
+		return k, nil
+	}
+	return "", fmt.Errorf("unknown document type %q (use auto, text, html, eml or mbox)", name)
+}
+
+// DetectKind guesses the document kind from the file name and its first bytes
+func DetectKind(filename string, head []byte) Kind {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".html", ".htm", ".xhtml":
+		return KindHTML
+	case ".eml":
+		return KindEML
+	case ".mbox", ".mbx":
+		return KindMbox
+	}
+
+	trimmed := bytes.TrimLeft(head, " \t\r\n")
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("From ")):
+		return KindMbox
+	case looksLikeMessage(trimmed):
+		return KindEML
+	}
+	lower := bytes.ToLower(head)
+	if bytes.Contains(lower, []byte("<html")) || bytes.Contains(lower, []byte("<!doctype html")) ||
+		bytes.Contains(lower, []byte("<a ")) || bytes.Contains(lower, []byte("<body")) {
+		return KindHTML
+	}
+	return KindText
+}
+
+// looksLikeMessage reports whether head starts with RFC 5322 header fields
+func looksLikeMessage(head []byte) bool {
+	line, _, _ := bytes.Cut(head, []byte("\n"))
+	name, _, ok := bytes.Cut(line, []byte(":"))
+	if !ok || len(name) == 0 || bytes.ContainsAny(name, " \t") {
+		return false
+	}
+	switch strings.ToLower(string(name)) {
+	case "from", "to", "received", "return-path", "delivered-to", "message-id",
+		"date", "subject", "mime-version", "x-mailer", "reply-to", "cc":
+		return true
+	}
+	return false
+}
+
+// Address is an extracted address
+type Address struct {
+	Email string `json:"email"`
+	// Source is where the address was first found
+	Source string `json:"source"`
+	// Count is how many times the address was found
+	Count int `json:"count"`
+}
+
+// Options control extraction
+type Options struct {
+	// Kind forces the document kind instead of detecting it
+	Kind Kind
+	// Body also scans message bodies of EML and mbox input, not just the
+	// address headers
+	Body bool
+}
+
+// Extractor collects addresses across documents, deduplicated
+// case-insensitively in first-seen order
+type Extractor struct {
+	opts  Options
+	index map[string]*Address
+	list  []*Address
+}
+
+// New creates an extractor
+func New(opts Options) *Extractor {
+	return &Extractor{opts: opts, index: make(map[string]*Address)}
+}
+
+// Addresses returns the unique addresses found so far
+func (e *Extractor) Addresses() []*Address {
+	return e.list
+}
+
+// Read extracts addresses from r. name is used to detect the document kind
+// when the options do not force one.
+func (e *Extractor) Read(r io.Reader, name string) error {
+	br := bufio.NewReader(r)
+	kind := e.opts.Kind
+	if kind == "" {
+		head, _ := br.Peek(4096)
+		kind = DetectKind(name, head)
+	}
+
+	if kind == KindMbox {
+		return e.Mbox(br)
+	}
+
+	data, err := io.ReadAll(br)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	switch kind {
+	case KindHTML:
+		e.HTML(string(data))
+	case KindEML:
+		return e.Message(bytes.NewReader(data))
+	default:
+		e.Text(string(data))
+	}
+	return nil
+}
+
+// Text extracts plain and obfuscated addresses from free text
+func (e *Extractor) Text(s string) {
+	e.text(s, nil)
+}
+
+// text is Text, skipping one plain match for every occurrence already
+// counted elsewhere (keyed by matchKey)
+func (e *Extractor) text(s string, counted map[string]int) {
+	plain := make(map[string]int)
+	for _, m := range emailPattern.FindAllString(s, -1) {
+		plain[m]++
+		if key := matchKey(m); counted[key] > 0 {
+			counted[key]--
+			continue
+		}
+		e.add(m, SourceText)
+	}
+
+	deobfuscated := bracketDotPattern.ReplaceAllString(bracketAtPattern.ReplaceAllString(s, "@"), ".")
+	deobfuscated = spelledPattern.ReplaceAllStringFunc(deobfuscated, func(m string) string {
+		parts := spelledPattern.FindStringSubmatch(m)
+		return parts[1] + "@" + spelledDot.ReplaceAllString(parts[2], ".")
+	})
+	if deobfuscated != s {
+		for _, m := range emailPattern.FindAllString(deobfuscated, -1) {
+			if plain[m] > 0 {
+				// Already counted above
+				plain[m]--
+				continue
+			}
+			e.add(m, SourceObfuscated)
+		}
+	}
+}
+
+// HTML extracts addresses from mailto: links and from the visible text,
+// with entities decoded (&#64; and friends)
+func (e *Extractor) HTML(s string) {
+	// A mailto: address is usually also the link text; count it once
+	mailto := make(map[string]int)
+	for _, m := range mailtoPattern.FindAllStringSubmatch(s, -1) {
+		target := html.UnescapeString(m[1])
+		target, _, _ = strings.Cut(target, "?")
+		if unescaped, err := url.PathUnescape(target); err == nil {
+			target = unescaped
+		}
+		for _, addr := range strings.Split(target, ",") {
+			if found := emailPattern.FindString(addr); found != "" {
+				mailto[matchKey(found)]++
+				e.add(found, SourceMailto)
+			}
+		}
+	}
+
+	e.text(html.UnescapeString(tagPattern.ReplaceAllString(s, " ")), mailto)
+}
+
+// matchKey identifies a raw match the way add does
+func matchKey(m string) string {
+	return strings.ToLower(strings.Trim(m, ".-'"))
+}
+
+// Message extracts addresses from the headers of one RFC 5322 message, and
+// from its body when Options.Body is set
+func (e *Extractor) Message(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read message: %w", err)
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		// Not a parseable message: fall back to scanning it as text
+		e.Text(string(data))
+		return nil
+	}
+
+	for _, name := range messageHeaders {
+		source := sourceHeaderPrefix + strings.ToLower(name)
+		for _, value := range msg.Header[name] {
+			list, err := mail.ParseAddressList(value)
+			if err != nil {
+				// Malformed header: take whatever looks like an address
+				for _, m := range emailPattern.FindAllString(value, -1) {
+					e.add(m, source)
+				}
+				continue
+			}
+			for _, addr := range list {
+				e.add(addr.Address, source)
+			}
+		}
+	}
+
+	if e.opts.Body {
+		body, err := io.ReadAll(msg.Body)
+		if err != nil {
+			return fmt.Errorf("failed to read message body: %w", err)
+		}
+		if strings.Contains(strings.ToLower(msg.Header.Get("Content-Type")), "html") ||
+			bytes.Contains(bytes.ToLower(body), []byte("<html")) {
+			e.HTML(string(body))
+		} else {
+			e.Text(string(body))
+		}
+	}
+	return nil
+}
+
+// Mbox extracts addresses from every message of an mbox file. Messages
+// start at lines beginning with "From ".
+func (e *Extractor) Mbox(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var msg bytes.Buffer
+	flush := func() error {
+		if msg.Len() == 0 {
+			return nil
+		}
+		err := e.Message(bytes.NewReader(msg.Bytes()))
+		msg.Reset()
+		return err
+	}
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if bytes.HasPrefix(line, []byte("From ")) {
+			if err := flush(); err != nil {
+				return err
+			}
+			continue
+		}
+		msg.Write(line)
+		msg.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read mbox: %w", err)
+	}
+	return flush()
+}
+
+// add records one occurrence of email
+func (e *Extractor) add(email, source string) {
+	email = strings.Trim(email, ".-'")
+	at := strings.LastIndexByte(email, '@')
+	if at <= 0 || at == len(email)-1 {
+		return
+	}
+	email = email[:at] + "@" + strings.ToLower(email[at+1:])
+	for _, suffix := range skipDomainSuffixes {
+		if strings.HasSuffix(email, suffix) {
+			return
+		}
+	}
+
+	key := strings.ToLower(email)
+	if a, ok := e.index[key]; ok {
+		a.Count++
+		return
+	}
+	a := &Address{Email: email, Source: source, Count: 1}
+	e.index[key] = a
+	e.list = append(e.list, a)
+}
@@ -0,0 +1,640 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Format represents an input file format
+type Format string
+
+const (
+	FormatTXT   Format = "txt"
+	FormatCSV   Format = "csv"
+	FormatTSV   Format = "tsv"
+	FormatJSONL Format = "jsonl"
+	FormatXLSX  Format = "xlsx"
+)
+
+// emailColumnNames are header names recognised as the email column when
+// --email-column is not given (compared case-insensitively)
+var emailColumnNames = []string{
+	"email", "e-mail", "email_address", "email address", "emailaddress", "mail", "address",
+}
+
+// DetectFormat detects the input format from filename
+func DetectFormat(filename string) Format {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return FormatCSV
+	case ".tsv", ".tab":
+		return FormatTSV
+	case ".jsonl", ".ndjson":
+		return FormatJSONL
+	case ".xlsx":
+		return FormatXLSX
+	default:
+		return FormatTXT
+	}
+}
+
+// ParseFormat validates a format name given on the command line
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(name)); f {
+	case FormatTXT, FormatCSV, FormatTSV, FormatJSONL, FormatXLSX:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown input format %q (use txt, csv, tsv, jsonl or xlsx)", name)
+}
+
+// Record is one row of the input
+type Record struct {
+	// Row is the 1-based line the record starts on
+	Row int
+	// Email is the trimmed content of the email cell
+	Email string
+	// Fields holds the original columns of the row, keyed by column name
+	Fields map[string]string
+	// Err explains why the row could not be used. Email still holds the
+	// email cell when the row got that far.
+	Err string
+}
+
+// Data is the parsed content of an input file
+type Data struct {
+	Format Format
+	// Columns lists the original column names in input order. It is empty
+	// for plain text input.
+	Columns []string
+	// EmailColumn is the column the addresses were taken from
+	EmailColumn string
+	Records     []*Record
+}
+
+// Options control how an input file is read
+type Options struct {
+	// Format overrides detection from the file extension
+	Format Format
+	// EmailColumn selects the email column by header name or 1-based index.
+	// When empty it is detected from the header or the first data row.
+	EmailColumn string
+	// Sheet selects the worksheet of XLSX input by name or 1-based index.
+	// When empty the first sheet is read.
+	Sheet string
+}
+
+// sampleRows caps how many rows are buffered to detect the email column when
+// the header does not name it
+const sampleRows = 50
+
+// Reader reads input records one at a time, so large files and pipes are
+// never held in memory
+type Reader struct {
+	format      Format
+	columns     []string
+	seen        map[string]bool
+	emailColumn string
+
+	lines *bufio.Scanner
+	csv   *csv.Reader
+	sheet *sheetRows
+	row   int
+
+	// buffered holds rows read while detecting the email column
+	buffered []*Record
+}
+
+// NewReader creates a reader for r in the given format (txt when unset).
+// For CSV/TSV/XLSX the header is read immediately; when it does not identify
+// the email column, up to sampleRows rows are buffered to find it from the
+// data. An XLSX workbook is loaded whole; its rows are then read one at a
+// time.
+func NewReader(r io.Reader, opts Options) (*Reader, error) {
+	rd := &Reader{format: opts.Format, seen: make(map[string]bool)}
+	if rd.format == "" {
+		rd.format = FormatTXT
+	}
+
+	switch rd.format {
+	case FormatCSV, FormatTSV:
+		rd.csv = csv.NewReader(stripBOM(r))
+		rd.csv.FieldsPerRecord = -1
+		if rd.format == FormatTSV {
+			rd.csv.Comma = '\t'
+		}
+		if err := rd.readHeader(opts.EmailColumn); err != nil {
+			return nil, err
+		}
+	case FormatXLSX:
+		var err error
+		if rd.sheet, err = openSheet(r, opts.Sheet); err != nil {
+			return nil, err
+		}
+		if err := rd.readHeader(opts.EmailColumn); err != nil {
+			rd.sheet.close()
+			return nil, err
+		}
+	case FormatJSONL:
+		rd.lines = newLineScanner(stripBOM(r))
+		if err := rd.sampleJSONL(opts.EmailColumn); err != nil {
+			return nil, err
+		}
+	default:
+		rd.lines = newLineScanner(r)
+	}
+	return rd, nil
+}
+
+// Format returns the input format
+func (rd *Reader) Format() Format { return rd.format }
+
+// Columns returns the original column names seen so far in input order.
+// For JSONL new keys are appended as records introduce them.
+func (rd *Reader) Columns() []string { return rd.columns }
+
+// EmailColumn returns the column the addresses are taken from
+func (rd *Reader) EmailColumn() string { return rd.emailColumn }
+
+// Next returns the next record, or io.EOF at the end of the input
+func (rd *Reader) Next() (*Record, error) {
+	if len(rd.buffered) > 0 {
+		rec := rd.buffered[0]
+		rd.buffered = rd.buffered[1:]
+		rd.setEmail(rec)
+		return rec, nil
+	}
+
+	switch rd.format {
+	case FormatCSV, FormatTSV, FormatXLSX:
+		rec, err := rd.readDelimited()
+		if err != nil {
+			return nil, err
+		}
+		rd.setEmail(rec)
+		return rec, nil
+	case FormatJSONL:
+		rec, err := rd.readJSONL()
+		if err != nil {
+			return nil, err
+		}
+		rd.setEmail(rec)
+		return rec, nil
+	default:
+		return rd.readText()
+	}
+}
+
+// ReadFile reads all records from filename
+func ReadFile(filename string, opts Options) (*Data, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer f.Close()
+
+	if opts.Format == "" {
+		opts.Format = DetectFormat(filename)
+	}
+	return Read(f, opts)
+}
+
+// Read reads all records from r in the given format (txt when unset)
+func Read(r io.Reader, opts Options) (*Data, error) {
+	rd, err := NewReader(r, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &Data{Format: rd.Format()}
+	for {
+		rec, err := rd.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		data.Records = append(data.Records, rec)
+	}
+	data.Columns = rd.Columns()
+	data.EmailColumn = rd.EmailColumn()
+	return data, nil
+}
+
+// readText reads one address per line, skipping blanks and # comments
+func (rd *Reader) readText() (*Record, error) {
+	for rd.lines.Scan() {
+		rd.row++
+		line := strings.TrimSpace(rd.lines.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		return &Record{Row: rd.row, Email: line}, nil
+	}
+	if err := rd.lines.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+	return nil, io.EOF
+}
+
+// readHeader reads the CSV/TSV/XLSX header and resolves the email column.
+// The first record is the header unless its email cell already holds an
+// address, in which case columns are named column1, column2, ...
+func (rd *Reader) readHeader(want string) error {
+	first, line, err := rd.readValues()
+	for err == nil && isBlankRecord(first) {
+		// Sheets often start with blank rows
+		first, line, err = rd.readValues()
+	}
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read header: %w", err)
+	}
+
+	header := trimAll(first)
+	col, err := emailColumnIndex(header, want)
+	if err != nil {
+		return err
+	}
+
+	if col >= 0 && strings.Contains(header[col], "@") {
+		rd.columns = numberedColumns(len(header))
+		rd.emailColumn = rd.columns[col]
+		rd.row = line
+		rd.buffered = append(rd.buffered, rd.delimitedRecord(first, nil, line))
+		return nil
+	}
+	rd.columns = uniqueColumns(header)
+	if col >= 0 {
+		rd.emailColumn = rd.columns[col]
+		return nil
+	}
+
+	// Guess the column from the first rows holding an address
+	var rows [][]string
+	for len(rd.buffered) < sampleRows {
+		rec, err := rd.readDelimited()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		rd.buffered = append(rd.buffered, rec)
+		rows = append(rows, fieldValues(rec, rd.columns))
+		if col = guessEmailColumn(rows, len(rd.columns)); col >= 0 {
+			break
+		}
+	}
+	if col < 0 {
+		return fmt.Errorf("could not find an email column in %v (use --email-column)", rd.columns)
+	}
+	rd.emailColumn = rd.columns[col]
+	return nil
+}
+
+// readDelimited reads the next non-blank CSV/TSV record or sheet row
+func (rd *Reader) readDelimited() (*Record, error) {
+	for {
+		values, line, err := rd.readValues()
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		var perr *csv.ParseError
+		switch {
+		case errors.As(err, &perr):
+			return rd.delimitedRecord(values, err, perr.StartLine), nil
+		case err != nil:
+			return nil, fmt.Errorf("failed to read input: %w", err)
+		case isBlankRecord(values):
+			continue
+		}
+		return rd.delimitedRecord(values, nil, line), nil
+	}
+}
+
+// readValues reads the next CSV/TSV record or sheet row and the line it
+// starts on
+func (rd *Reader) readValues() ([]string, int, error) {
+	if rd.sheet != nil {
+		return rd.sheet.next(len(rd.columns))
+	}
+	values, err := rd.csv.Read()
+	if err != nil {
+		return values, 0, err
+	}
+	line, _ := rd.csv.FieldPos(0)
+	return values, line, nil
+}
+
+// delimitedRecord maps values onto the header. The email cell is resolved
+// later by setEmail, once the email column is known.
+func (rd *Reader) delimitedRecord(values []string, err error, line int) *Record {
+	rec := &Record{Row: line, Fields: make(map[string]string, len(rd.columns))}
+	for i, value := range values {
+		if i < len(rd.columns) {
+			rec.Fields[rd.columns[i]] = value
+		}
+	}
+	switch {
+	case err != nil:
+		rec.Err = "malformed row: " + err.Error()
+	case len(values) > len(rd.columns):
+		rec.Err = fmt.Sprintf("row has %d columns, header has %d", len(values), len(rd.columns))
+	}
+	return rec
+}
+
+// sampleJSONL buffers records until the email field can be resolved
+func (rd *Reader) sampleJSONL(want string) error {
+	col := -1
+	var lastErr error
+	for len(rd.buffered) < sampleRows {
+		rec, err := rd.readJSONL()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		rd.buffered = append(rd.buffered, rec)
+
+		col, lastErr = emailColumnIndex(rd.columns, want)
+		if col < 0 && lastErr == nil {
+			rows := make([][]string, 0, len(rd.buffered))
+			for _, b := range rd.buffered {
+				rows = append(rows, fieldValues(b, rd.columns))
+			}
+			col = guessEmailColumn(rows, len(rd.columns))
+		}
+		if col >= 0 {
+			break
+		}
+	}
+	if lastErr != nil {
+		return lastErr
+	}
+	if col < 0 {
+		if len(rd.buffered) == 0 {
+			return nil
+		}
+		return fmt.Errorf("could not find an email field in %v (use --email-column)", rd.columns)
+	}
+	rd.emailColumn = rd.columns[col]
+	return nil
+}
+
+// readJSONL reads the next JSON object. Non-string values are kept in their
+// JSON form.
+func (rd *Reader) readJSONL() (*Record, error) {
+	for rd.lines.Scan() {
+		rd.row++
+		line := bytes.TrimSpace(rd.lines.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		rec := &Record{Row: rd.row}
+		fields, keys, err := decodeObject(line)
+		if err != nil {
+			rec.Err = "malformed row: " + err.Error()
+			return rec, nil
+		}
+		rec.Fields = fields
+		for _, k := range keys {
+			if !rd.seen[k] {
+				rd.seen[k] = true
+				rd.columns = append(rd.columns, k)
+			}
+		}
+		return rec, nil
+	}
+	if err := rd.lines.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+	return nil, io.EOF
+}
+
+// setEmail fills in the email cell of a tabular record
+func (rd *Reader) setEmail(rec *Record) {
+	if rec.Fields == nil || rd.emailColumn == "" {
+		return
+	}
+	value, ok := rec.Fields[rd.emailColumn]
+	if !ok {
+		if rec.Err == "" {
+			rec.Err = fmt.Sprintf("email column %q is missing", rd.emailColumn)
+		}
+		return
+	}
+	rec.Email = strings.TrimSpace(value)
+}
+
+// decodeObject decodes a JSON object into string values and its keys in order
+func decodeObject(line []byte) (map[string]string, []string, error) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, nil, err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '{' {
+		return nil, nil, fmt.Errorf("expected a JSON object")
+	}
+
+	fields := make(map[string]string)
+	var keys []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key := tok.(string)
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, nil, err
+		}
+		var s string
+		if json.Unmarshal(raw, &s) != nil {
+			s = string(raw)
+			if s == "null" {
+				s = ""
+			}
+		}
+		if _, dup := fields[key]; !dup {
+			keys = append(keys, key)
+		}
+		fields[key] = s
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, nil, err
+	}
+	if dec.More() {
+		return nil, nil, fmt.Errorf("unexpected data after JSON object")
+	}
+	return fields, keys, nil
+}
+
+// emailColumnIndex resolves the email column from an explicit name or
+// 1-based index, or from a well-known header name. It returns -1 when the
+// column has to be guessed from the data.
+func emailColumnIndex(columns []string, want string) (int, error) {
+	if want != "" {
+		for i, c := range columns {
+			if strings.EqualFold(c, want) {
+				return i, nil
+			}
+		}
+		if n, err := strconv.Atoi(want); err == nil {
+			if n < 1 || n > len(columns) {
+				return 0, fmt.Errorf("email column %d out of range (input has %d columns)", n, len(columns))
+			}
+			return n - 1, nil
+		}
+		return 0, fmt.Errorf("email column %q not found in %v", want, columns)
+	}
+
+	for _, name := range emailColumnNames {
+		for i, c := range columns {
+			if strings.EqualFold(c, name) {
+				return i, nil
+			}
+		}
+	}
+	for i, c := range columns {
+		if strings.Contains(c, "@") {
+			return i, nil
+		}
+	}
+	return -1, nil
+}
+
+// guessEmailColumn picks the column whose values most often contain an @,
+// looking at the first rows only
+func guessEmailColumn(rows [][]string, width int) int {
+	const sample = 50
+
+	counts := make([]int, width)
+	for i, rec := range rows {
+		if i == sample {
+			break
+		}
+		for j, value := range rec {
+			if j < width && strings.Contains(value, "@") {
+				counts[j]++
+			}
+		}
+	}
+
+	best := -1
+	for j, n := range counts {
+		if n > 0 && (best < 0 || n > counts[best]) {
+			best = j
+		}
+	}
+	return best
+}
+
+// uniqueColumns names empty header cells and disambiguates repeated ones so
+// every column keeps its value. Generated names never clash with a header
+// cell or with another column.
+func uniqueColumns(header []string) []string {
+	taken := make(map[string]bool, len(header))
+	for _, name := range header {
+		if name != "" {
+			taken[name] = true
+		}
+	}
+
+	out := make([]string, len(header))
+	used := make(map[string]bool, len(header))
+	for i, name := range header {
+		if name == "" {
+			name = fmt.Sprintf("column%d", i+1)
+			if taken[name] {
+				name = freeColumnName(name, taken, used)
+			}
+		} else if used[name] {
+			name = freeColumnName(name, taken, used)
+		}
+		used[name] = true
+		out[i] = name
+	}
+	return out
+}
+
+// freeColumnName returns the first of base_2, base_3, ... that is neither a
+// header cell nor already used
+func freeColumnName(base string, taken, used map[string]bool) string {
+	for n := 2; ; n++ {
+		name := fmt.Sprintf("%s_%d", base, n)
+		if !taken[name] && !used[name] {
+			return name
+		}
+	}
+}
+
+// numberedColumns returns column1..columnN for headerless input
+func numberedColumns(n int) []string {
+	out := make([]string, n)
+	for i := range out {
+		out[i] = fmt.Sprintf("column%d", i+1)
+	}
+	return out
+}
+
+// fieldValues returns the values of rec in column order
+func fieldValues(rec *Record, columns []string) []string {
+	values := make([]string, len(columns))
+	for i, c := range columns {
+		values[i] = rec.Fields[c]
+	}
+	return values
+}
+
+// newLineScanner returns a scanner that allows lines up to 1 MB (handles
+// extremely long lines gracefully)
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	return scanner
+}
+
+func trimAll(values []string) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = strings.TrimSpace(v)
+	}
+	return out
+}
+
+func isBlankRecord(rec []string) bool {
+	for _, v := range rec {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// stripBOM drops a leading UTF-8 byte order mark, which spreadsheet exports
+// often prepend to the header
+func stripBOM(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	if b, err := br.Peek(3); err == nil && bytes.Equal(b, []byte{0xEF, 0xBB, 0xBF}) {
+		br.Discard(3) //nolint:errcheck
+	}
+	return br
+}
@@ -0,0 +1,94 @@
+package input
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// sheetRows reads the rows of one worksheet as displayed in Excel, so
+// formatted numbers such as zip codes keep their leading zeros
+type sheetRows struct {
+	book *excelize.File
+	rows *excelize.Rows
+	row  int
+	done bool
+}
+
+// openSheet opens the workbook in r and selects a sheet by name or 1-based
+// index; the first sheet when want is empty
+func openSheet(r io.Reader, want string) (*sheetRows, error) {
+	book, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open workbook: %w", err)
+	}
+
+	sheets := book.GetSheetList()
+	name := ""
+	switch {
+	case len(sheets) == 0:
+		err = fmt.Errorf("workbook has no sheets")
+	case want == "":
+		name = sheets[0]
+	default:
+		for _, s := range sheets {
+			if strings.EqualFold(s, want) {
+				name = s
+			}
+		}
+		if n, convErr := strconv.Atoi(want); name == "" && convErr == nil {
+			if n < 1 || n > len(sheets) {
+				err = fmt.Errorf("sheet %d out of range (workbook has %d sheets)", n, len(sheets))
+			} else {
+				name = sheets[n-1]
+			}
+		}
+		if name == "" && err == nil {
+			err = fmt.Errorf("sheet %q not found in %v", want, sheets)
+		}
+	}
+
+	var rows *excelize.Rows
+	if err == nil {
+		rows, err = book.Rows(name)
+	}
+	if err != nil {
+		book.Close()
+		return nil, err
+	}
+	return &sheetRows{book: book, rows: rows}, nil
+}
+
+// next returns the cells of the next row and its 1-based row number.
+// Trailing empty cells are padded up to width.
+func (s *sheetRows) next(width int) ([]string, int, error) {
+	if s.done {
+		return nil, 0, io.EOF
+	}
+	if !s.rows.Next() {
+		err := s.rows.Error()
+		s.close()
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read sheet: %w", err)
+		}
+		return nil, 0, io.EOF
+	}
+	s.row++
+	values, err := s.rows.Columns()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read sheet row %d: %w", s.row, err)
+	}
+	for len(values) < width {
+		values = append(values, "")
+	}
+	return values, s.row, nil
+}
+
+func (s *sheetRows) close() {
+	s.done = true
+	s.rows.Close()
+	s.book.Close()
+}
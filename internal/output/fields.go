@@ -0,0 +1,522 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/nephila016/emailchecker/internal/verifier"
+)
+
+// Quote modes for CSV output
+const (
+	// QuoteMinimal quotes only fields that need it
+	QuoteMinimal = "minimal"
+	// QuoteAll quotes every field
+	QuoteAll = "all"
+)
+
+// Time formats besides Go layouts
+const (
+	TimeRFC3339     = "rfc3339"
+	TimeRFC3339Nano = "rfc3339nano"
+	TimeUnix        = "unix"
+	TimeUnixMilli   = "unix_ms"
+	// TimeLocal is local time as 2006-01-02 15:04:05
+	TimeLocal = "local"
+)
+
+// Options control which result fields writers emit and how values are
+// formatted. The zero value gives every format its default layout.
+type Options struct {
+	// Fields select what is written, in order. Each is a result field as
+	// named in JSON output or a dotted path into nested data (input.company,
+	// mx_records.0, score_breakdown.0.factor), optionally renamed with
+	// ":Header". "default" stands for the format's default fields and "all"
+	// for every field.
+	Fields []string
+	// InputColumns are the original input columns carried through. CSV,
+	// XLSX and TXT expand the input field into one column each.
+	InputColumns []string
+	// EmailColumn is the input column holding the address. It is left out
+	// of the expanded input columns when the email field is written too.
+	EmailColumn string
+	// Delimiter separates CSV columns (default ',') and TXT fields (default
+	// tab)
+	Delimiter rune
+	// Quote is QuoteMinimal (default) or QuoteAll
+	Quote string
+	// TimeFormat is rfc3339 (default), rfc3339nano, unix, unix_ms, local or
+	// a Go time layout such as "02/01/2006 15:04"
+	TimeFormat string
+	// Settings describe the run, for writers that record it (SQLite)
+	Settings []Setting
+}
+
+// csvDefaultFields are the fields CSV output has always carried
+var csvDefaultFields = []string{
+	"email",
+	"valid",
+	"status",
+	"status_code",
+	"reason",
+	"disposable",
+	"role_account",
+	"free_provider",
+	"catch_all",
+	"mx_host",
+	"confidence_score",
+	"latency_ms",
+	"verified_at",
+	"syntax_error",
+	"canonical_email",
+	"did_you_mean",
+	"tags",
+}
+
+// resultFields are the JSON names of verifier.Result fields in declaration
+// order. resultTimeFields maps those holding a time.Time to their index.
+var resultFields, resultTimeFields = func() ([]string, map[string]int) {
+	var names []string
+	times := make(map[string]int)
+	t := reflect.TypeOf(verifier.Result{})
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || name == "" || name == "-" {
+			continue
+		}
+		names = append(names, name)
+		if f.Type == reflect.TypeOf(time.Time{}) {
+			times[name] = i
+		}
+	}
+	return names, times
+}()
+
+// ResultFields returns the result field names usable in Options.Fields
+func ResultFields() []string {
+	return append([]string{}, resultFields...)
+}
+
+// ParseDelimiter parses a delimiter given as a single character or as one
+// of tab, comma, semicolon, pipe or space
+func ParseDelimiter(s string) (rune, error) {
+	switch strings.ToLower(s) {
+	case "":
+		return 0, nil
+	case "tab", `\t`:
+		return '\t', nil
+	case "comma":
+		return ',', nil
+	case "semicolon":
+		return ';', nil
+	case "pipe":
+		return '|', nil
+	case "space":
+		return ' ', nil
+	}
+	r, size := utf8.DecodeRuneInString(s)
+	if size != len(s) || r == utf8.RuneError {
+		return 0, fmt.Errorf("invalid delimiter %q (use a single character, tab, comma, semicolon, pipe or space)", s)
+	}
+	if r == '"' || r == '\r' || r == '\n' {
+		return 0, fmt.Errorf("invalid delimiter %q", s)
+	}
+	return r, nil
+}
+
+// column is one output field
+type column struct {
+	header string
+	path   []string
+	time   bool
+	// optional columns come from "default" or "all" and are left out of
+	// JSON objects when missing, as omitempty fields are
+	optional bool
+	// passthrough columns come from expanding the input field
+	passthrough bool
+}
+
+// layout is a compiled set of Options for one format
+type layout struct {
+	columns    []column
+	delim      rune
+	quoteAll   bool
+	timeFormat string
+	// project writes JSON objects built from the columns rather than the
+	// whole result
+	project bool
+}
+
+// newLayout compiles opts for a format
+func newLayout(format Format, opts Options) (*layout, error) {
+	l := &layout{delim: opts.Delimiter, timeFormat: opts.TimeFormat}
+
+	switch opts.Quote {
+	case "", QuoteMinimal:
+	case QuoteAll:
+		l.quoteAll = true
+	default:
+		return nil, fmt.Errorf("unknown quote mode %q (use minimal or all)", opts.Quote)
+	}
+	if err := checkTimeFormat(opts.TimeFormat); err != nil {
+		return nil, err
+	}
+
+	tabular := format == FormatCSV || format == FormatTXT || format == FormatXLSX
+	if l.delim == 0 {
+		l.delim = ','
+		if format == FormatTXT {
+			l.delim = '\t'
+		}
+	}
+
+	var defaults []string
+	switch format {
+	case FormatCSV, FormatXLSX:
+		defaults = append([]string{"input"}, csvDefaultFields...)
+	case FormatTXT:
+		defaults = []string{"email"}
+	default:
+		defaults = resultFields
+		l.project = opts.Fields != nil || opts.TimeFormat != ""
+	}
+
+	specs := opts.Fields
+	if specs == nil {
+		specs = []string{"default"}
+	}
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		name, header, renamed := strings.Cut(spec, ":")
+		name = strings.ToLower(strings.TrimSpace(name))
+		header = strings.TrimSpace(header)
+
+		var names []string
+		switch name {
+		case "":
+			continue
+		case "default", "all":
+			if renamed {
+				return nil, fmt.Errorf("field %q cannot be renamed", name)
+			}
+			names = defaults
+			if name == "all" {
+				names = resultFields
+			}
+		default:
+			names = []string{name}
+		}
+
+		for _, n := range names {
+			path := strings.Split(n, ".")
+			if !isResultField(path[0]) {
+				return nil, fmt.Errorf("unknown field %q (fields: %s)", n, strings.Join(resultFields, ", "))
+			}
+			if tabular && n == "input" {
+				// One column per original input column
+				for _, c := range opts.InputColumns {
+					l.columns = append(l.columns, column{header: c, path: []string{"input", c}, passthrough: true})
+				}
+				continue
+			}
+
+			_, isTime := resultTimeFields[n]
+			c := column{header: n, path: path, time: isTime, optional: len(names) > 1}
+			if len(path) == 2 && path[0] == "input" {
+				c.header = path[1]
+			}
+			if renamed {
+				c.header = header
+			}
+			l.columns = append(l.columns, c)
+		}
+	}
+	if opts.EmailColumn != "" && l.writes("email") {
+		kept := l.columns[:0]
+		for _, c := range l.columns {
+			if !c.passthrough || c.path[1] != opts.EmailColumn {
+				kept = append(kept, c)
+			}
+		}
+		l.columns = kept
+	}
+	if len(l.columns) == 0 && (tabular || l.project) {
+		return nil, fmt.Errorf("no output fields selected")
+	}
+	return l, nil
+}
+
+// writes reports whether the layout has a column for the result field name
+func (l *layout) writes(name string) bool {
+	for _, c := range l.columns {
+		if len(c.path) == 1 && c.path[0] == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isResultField(name string) bool {
+	for _, f := range resultFields {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTimeFormat rejects a time format that is neither a known name nor
+// a Go layout
+func checkTimeFormat(format string) error {
+	switch strings.ToLower(format) {
+	case "", TimeRFC3339, TimeRFC3339Nano, TimeUnix, TimeUnixMilli, TimeLocal:
+		return nil
+	}
+	for _, element := range []string{"2006", "06", "01", "Jan", "02", "_2", "15", "03", "04", "05"} {
+		if strings.Contains(format, element) {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown time format %q (use rfc3339, rfc3339nano, unix, unix_ms, local or a Go layout such as 2006-01-02T15:04)", format)
+}
+
+// formatTime formats t per the layout's time format. numeric is set for
+// Unix timestamps, which JSON writes as numbers.
+func (l *layout) formatTime(t time.Time) (s string, numeric bool) {
+	if t.IsZero() {
+		return "", false
+	}
+	switch strings.ToLower(l.timeFormat) {
+	case "", TimeRFC3339:
+		return t.Format(time.RFC3339), false
+	case TimeRFC3339Nano:
+		return t.Format(time.RFC3339Nano), false
+	case TimeUnix:
+		return strconv.FormatInt(t.Unix(), 10), true
+	case TimeUnixMilli:
+		return strconv.FormatInt(t.UnixMilli(), 10), true
+	case TimeLocal:
+		return t.Local().Format("2006-01-02 15:04:05"), false
+	}
+	return t.Format(l.timeFormat), false
+}
+
+// headers returns the column headers
+func (l *layout) headers() []string {
+	headers := make([]string, len(l.columns))
+	for i, c := range l.columns {
+		headers[i] = c.header
+	}
+	return headers
+}
+
+// values looks up every column in the result's JSON form. A missing value
+// is nil.
+func (l *layout) values(result *verifier.Result) ([]json.RawMessage, error) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	values := make([]json.RawMessage, len(l.columns))
+	for i, c := range l.columns {
+		if c.time {
+			values[i] = l.timeValue(result, c.path[0])
+			continue
+		}
+		values[i] = lookupPath(fields[c.path[0]], c.path[1:])
+	}
+	return values, nil
+}
+
+// timeValue formats a time field as a JSON value
+func (l *layout) timeValue(result *verifier.Result, name string) json.RawMessage {
+	t, _ := reflect.ValueOf(result).Elem().Field(resultTimeFields[name]).Interface().(time.Time)
+	s, numeric := l.formatTime(t)
+	switch {
+	case s == "":
+		return nil
+	case numeric:
+		return json.RawMessage(s)
+	}
+	data, _ := json.Marshal(s)
+	return data
+}
+
+// lookupPath descends into objects by key and arrays by index
+func lookupPath(v json.RawMessage, path []string) json.RawMessage {
+	for _, key := range path {
+		if len(v) == 0 {
+			return nil
+		}
+		switch v[0] {
+		case '{':
+			var m map[string]json.RawMessage
+			if json.Unmarshal(v, &m) != nil {
+				return nil
+			}
+			v = m[key]
+		case '[':
+			var a []json.RawMessage
+			i, err := strconv.Atoi(key)
+			if json.Unmarshal(v, &a) != nil || err != nil || i < 0 || i >= len(a) {
+				return nil
+			}
+			v = a[i]
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
+// cells returns the columns as text: strings unquoted, lists of strings
+// joined with ";", other nested values as compact JSON
+func (l *layout) cells(result *verifier.Result) ([]string, error) {
+	values, err := l.values(result)
+	if err != nil {
+		return nil, err
+	}
+	cells := make([]string, len(values))
+	for i, v := range values {
+		cells[i] = cellText(v)
+	}
+	return cells, nil
+}
+
+func cellText(v json.RawMessage) string {
+	if len(v) == 0 || string(v) == "null" {
+		return ""
+	}
+	switch v[0] {
+	case '"':
+		var s string
+		if json.Unmarshal(v, &s) == nil {
+			return s
+		}
+	case '[':
+		var list []string
+		if json.Unmarshal(v, &list) == nil {
+			return strings.Join(list, ";")
+		}
+	}
+	return string(v)
+}
+
+// typedValues returns the columns as Go values for typed spreadsheet cells:
+// numbers as int64 or float64, booleans as bool, timestamps as time.Time
+// unless a time format was chosen, text and nested data as for cells. A
+// missing value is nil.
+func (l *layout) typedValues(result *verifier.Result) ([]interface{}, error) {
+	values, err := l.values(result)
+	if err != nil {
+		return nil, err
+	}
+	typed := make([]interface{}, len(values))
+	for i, v := range values {
+		c := l.columns[i]
+		if c.time && l.timeFormat == "" {
+			t, _ := reflect.ValueOf(result).Elem().Field(resultTimeFields[c.path[0]]).Interface().(time.Time)
+			if !t.IsZero() {
+				typed[i] = t
+			}
+			continue
+		}
+		if len(v) == 0 || string(v) == "null" {
+			continue
+		}
+		switch {
+		case string(v) == "true" || string(v) == "false":
+			typed[i] = string(v) == "true"
+		case v[0] == '-' || (v[0] >= '0' && v[0] <= '9'):
+			if n, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+				typed[i] = n
+			} else if f, err := strconv.ParseFloat(string(v), 64); err == nil {
+				typed[i] = f
+			} else {
+				typed[i] = string(v)
+			}
+		default:
+			typed[i] = cellText(v)
+		}
+	}
+	return typed, nil
+}
+
+// encodeJSON encodes a result as one compact JSON object: the whole result,
+// or the selected columns in order
+func (l *layout) encodeJSON(result *verifier.Result) ([]byte, error) {
+	if !l.project {
+		return json.Marshal(result)
+	}
+	values, err := l.values(result)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	first := true
+	for i, c := range l.columns {
+		if values[i] == nil && c.optional {
+			continue
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		key, _ := json.Marshal(c.header)
+		buf.Write(key)
+		buf.WriteByte(':')
+		if values[i] == nil {
+			buf.WriteString("null")
+		} else {
+			buf.Write(values[i])
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// csvLine formats one CSV record, quoting like encoding/csv unless every
+// field is to be quoted
+func (l *layout) csvLine(cells []string) string {
+	var b strings.Builder
+	for i, cell := range cells {
+		if i > 0 {
+			b.WriteRune(l.delim)
+		}
+		if !l.quoteAll && !l.needsQuotes(cell) {
+			b.WriteString(cell)
+			continue
+		}
+		b.WriteByte('"')
+		b.WriteString(strings.ReplaceAll(cell, `"`, `""`))
+		b.WriteByte('"')
+	}
+	b.WriteByte('\n')
+	return b.String()
+}
+
+func (l *layout) needsQuotes(cell string) bool {
+	if cell == "" {
+		return false
+	}
+	if cell == `\.` || strings.ContainsRune(cell, l.delim) || strings.ContainsAny(cell, "\"\r\n") {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(cell)
+	return unicode.IsSpace(r)
+}
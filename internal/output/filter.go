@@ -0,0 +1,378 @@
+package output
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/nephila016/emailchecker/internal/verifier"
+)
+
+// Filter is a parsed result filter such as
+//
+//	status=valid && !role && score>=70
+//	status=risky || (catch_all && domain=*.edu)
+//
+// A condition is a field name, optionally compared with =, !=, >, >=, < or
+// <= to a value. A bare field is true when it is set (true, non-zero or
+// non-empty). String comparisons ignore case and = accepts * and ? globs;
+// "tag" matches any of the result's tags. Conditions combine with !, && and
+// || and group with parentheses.
+type Filter struct {
+	expr string
+	root filterNode
+}
+
+// filterFields maps filter field names to result values. Values are bool,
+// float64, string or []string.
+var filterFields = map[string]func(r *verifier.Result) interface{}{
+	"email":                 func(r *verifier.Result) interface{} { return r.Email },
+	"domain":                func(r *verifier.Result) interface{} { return r.Domain },
+	"status":                func(r *verifier.Result) interface{} { return string(r.Status) },
+	"sub_status":            func(r *verifier.Result) interface{} { return r.SubStatus },
+	"reason":                func(r *verifier.Result) interface{} { return r.Reason },
+	"mx_host":               func(r *verifier.Result) interface{} { return r.MXHost },
+	"provider":              func(r *verifier.Result) interface{} { return r.MailboxProvider },
+	"role_category":         func(r *verifier.Result) interface{} { return r.RoleCategory },
+	"syntax_error":          func(r *verifier.Result) interface{} { return r.SyntaxError },
+	"did_you_mean":          func(r *verifier.Result) interface{} { return r.DidYouMean },
+	"tag":                   func(r *verifier.Result) interface{} { return r.Tags },
+	"valid":                 func(r *verifier.Result) interface{} { return r.Valid },
+	"disposable":            func(r *verifier.Result) interface{} { return r.Disposable },
+	"role":                  func(r *verifier.Result) interface{} { return r.RoleAccount },
+	"free":                  func(r *verifier.Result) interface{} { return r.FreeProvider },
+	"catch_all":             func(r *verifier.Result) interface{} { return r.CatchAll },
+	"accept_then_bounce":    func(r *verifier.Result) interface{} { return r.AcceptThenBounce },
+	"generated":             func(r *verifier.Result) interface{} { return r.Generated },
+	"syntax_valid":          func(r *verifier.Result) interface{} { return r.SyntaxValid },
+	"has_mx":                func(r *verifier.Result) interface{} { return r.HasMX },
+	"smtp_success":          func(r *verifier.Result) interface{} { return r.SMTPSuccess },
+	"tls":                   func(r *verifier.Result) interface{} { return r.TLSUsed },
+	"score":                 func(r *verifier.Result) interface{} { return float64(r.ConfidenceScore) },
+	"status_code":           func(r *verifier.Result) interface{} { return float64(r.StatusCode) },
+	"local_part_score":      func(r *verifier.Result) interface{} { return float64(r.LocalPartScore) },
+	"catch_all_probability": func(r *verifier.Result) interface{} { return r.CatchAllProbability },
+	"latency_ms":            func(r *verifier.Result) interface{} { return float64(r.LatencyMs) },
+}
+
+// filterAliases are alternative spellings of field names
+var filterAliases = map[string]string{
+	"role_account":     "role",
+	"free_provider":    "free",
+	"confidence_score": "score",
+	"confidence":       "score",
+	"tags":             "tag",
+	"catchall":         "catch_all",
+	"mailbox_provider": "provider",
+}
+
+// FilterFields returns the field names a filter can use, sorted
+func FilterFields() []string {
+	names := make([]string, 0, len(filterFields))
+	for name := range filterFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseFilter parses a filter expression
+func ParseFilter(expr string) (*Filter, error) {
+	tokens, err := lexFilter(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter %q: %w", expr, err)
+	}
+	p := &filterParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter %q: %w", expr, err)
+	}
+	return &Filter{expr: expr, root: root}, nil
+}
+
+// Match reports whether the result satisfies the filter
+func (f *Filter) Match(r *verifier.Result) bool {
+	return f.root.eval(r)
+}
+
+// String returns the filter expression
+func (f *Filter) String() string {
+	return f.expr
+}
+
+type filterNode interface {
+	eval(r *verifier.Result) bool
+}
+
+type notNode struct{ x filterNode }
+type andNode struct{ l, r filterNode }
+type orNode struct{ l, r filterNode }
+
+func (n notNode) eval(r *verifier.Result) bool { return !n.x.eval(r) }
+func (n andNode) eval(r *verifier.Result) bool { return n.l.eval(r) && n.r.eval(r) }
+func (n orNode) eval(r *verifier.Result) bool  { return n.l.eval(r) || n.r.eval(r) }
+
+// condNode compares a field with a value, or tests it is set when op is ""
+type condNode struct {
+	field string
+	get   func(r *verifier.Result) interface{}
+	op    string
+	value string
+	num   float64
+	isNum bool
+}
+
+func (c condNode) eval(r *verifier.Result) bool {
+	v := c.get(r)
+	if c.op == "" {
+		return isSet(v)
+	}
+
+	switch v := v.(type) {
+	case bool:
+		want, err := strconv.ParseBool(c.value)
+		if err != nil {
+			return false
+		}
+		return (v == want) == (c.op == "=")
+	case float64:
+		if !c.isNum {
+			return false
+		}
+		return compareNumbers(v, c.op, c.num)
+	case []string:
+		found := false
+		for _, s := range v {
+			if matchString(s, c.value) {
+				found = true
+				break
+			}
+		}
+		return found == (c.op == "=")
+	case string:
+		switch c.op {
+		case "=":
+			return matchString(v, c.value)
+		case "!=":
+			return !matchString(v, c.value)
+		}
+		return false
+	}
+	return false
+}
+
+func isSet(v interface{}) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		return v != ""
+	case []string:
+		return len(v) > 0
+	}
+	return false
+}
+
+func compareNumbers(a float64, op string, b float64) bool {
+	switch op {
+	case "=":
+		return a == b
+	case "!=":
+		return a != b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	}
+	return false
+}
+
+// matchString compares case-insensitively, with * and ? globs
+func matchString(s, pattern string) bool {
+	s, pattern = strings.ToLower(s), strings.ToLower(pattern)
+	if strings.ContainsAny(pattern, "*?") {
+		ok, _ := path.Match(pattern, s)
+		return ok
+	}
+	return s == pattern
+}
+
+type filterToken struct {
+	kind string // "ident", "op", "&&", "||", "!", "(", ")"
+	text string
+}
+
+// lexFilter splits an expression into tokens. Values may be quoted with
+// single or double quotes.
+func lexFilter(expr string) ([]filterToken, error) {
+	var tokens []filterToken
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case strings.HasPrefix(expr[i:], "&&"), strings.HasPrefix(expr[i:], "||"):
+			tokens = append(tokens, filterToken{kind: expr[i : i+2], text: expr[i : i+2]})
+			i += 2
+		case strings.HasPrefix(expr[i:], "!="), strings.HasPrefix(expr[i:], ">="), strings.HasPrefix(expr[i:], "<="):
+			tokens = append(tokens, filterToken{kind: "op", text: expr[i : i+2]})
+			i += 2
+		case c == '=' || c == '>' || c == '<':
+			tokens = append(tokens, filterToken{kind: "op", text: expr[i : i+1]})
+			i++
+		case c == '!' || c == '(' || c == ')':
+			tokens = append(tokens, filterToken{kind: string(c), text: string(c)})
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(expr[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quote")
+			}
+			tokens = append(tokens, filterToken{kind: "ident", text: expr[i+1 : i+1+end]})
+			i += end + 2
+		default:
+			start := i
+			for i < len(expr) && isFilterWordChar(rune(expr[i])) {
+				i++
+			}
+			if i == start {
+				return nil, fmt.Errorf("unexpected %q", string(c))
+			}
+			tokens = append(tokens, filterToken{kind: "ident", text: expr[start:i]})
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+	return tokens, nil
+}
+
+func isFilterWordChar(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("_-.@*?+:/", r) || r >= 0x80
+}
+
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+func (p *filterParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos].kind
+	}
+	return ""
+}
+
+func (p *filterParser) parseOr() (filterNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "||" {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filterNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "&&" {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (filterNode, error) {
+	switch p.peek() {
+	case "!":
+		p.pos++
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{x}, nil
+	case "(":
+		p.pos++
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return x, nil
+	case "ident":
+		return p.parseCond()
+	case "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+}
+
+func (p *filterParser) parseCond() (filterNode, error) {
+	name := strings.ToLower(p.tokens[p.pos].text)
+	p.pos++
+	if alias, ok := filterAliases[name]; ok {
+		name = alias
+	}
+	get, ok := filterFields[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q (fields: %s)", name, strings.Join(FilterFields(), ", "))
+	}
+
+	c := condNode{field: name, get: get}
+	if p.peek() != "op" {
+		return c, nil
+	}
+	c.op = p.tokens[p.pos].text
+	p.pos++
+	if p.peek() != "ident" {
+		return nil, fmt.Errorf("missing value after %s%s", name, c.op)
+	}
+	c.value = p.tokens[p.pos].text
+	p.pos++
+
+	if _, numeric := get(&verifier.Result{}).(float64); numeric {
+		n, err := strconv.ParseFloat(c.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s needs a number, got %q", name, c.value)
+		}
+		c.num, c.isNum = n, true
+		return c, nil
+	}
+	if c.op != "=" && c.op != "!=" {
+		return nil, fmt.Errorf("%s only supports = and !=", name)
+	}
+	if _, boolean := get(&verifier.Result{}).(bool); boolean {
+		if _, err := strconv.ParseBool(c.value); err != nil {
+			return nil, fmt.Errorf("%s needs true or false, got %q", name, c.value)
+		}
+	}
+	return c, nil
+}
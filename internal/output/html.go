@@ -0,0 +1,410 @@
+package output
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nephila016/emailchecker/internal/report"
+	"github.com/nephila016/emailchecker/internal/verifier"
+)
+
+// htmlTop is how many entries each breakdown of the HTML report lists
+const htmlTop = 15
+
+// HTMLWriter writes a self-contained HTML report: the run summary and
+// settings, breakdowns by status, sub-status, domain, catch-all and
+// disposable addresses and latency, and a searchable table of every
+// result. Table rows are spooled to a temporary file; the page is written
+// on Close.
+type HTMLWriter struct {
+	file     *os.File
+	rows     *os.File
+	buf      *bufio.Writer
+	stats    *report.Stats
+	summary  *Summary
+	settings []Setting
+	mu       sync.Mutex
+	count    int
+	err      error
+}
+
+// NewHTMLWriter writes the report to file once the run ends
+func NewHTMLWriter(file *os.File, settings []Setting) (*HTMLWriter, error) {
+	rows, err := os.CreateTemp("", "emailchecker-report-*.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create report buffer: %w", err)
+	}
+	return &HTMLWriter{
+		file:     file,
+		rows:     rows,
+		buf:      bufio.NewWriter(rows),
+		stats:    report.New(),
+		summary:  NewSummary(),
+		settings: settings,
+	}, nil
+}
+
+func (w *HTMLWriter) Write(result *verifier.Result) error {
+	w.stats.Add(result)
+	w.summary.Add(result)
+
+	var flags []string
+	for _, f := range []struct {
+		set  bool
+		name string
+	}{
+		{result.CatchAll, "catch-all"},
+		{result.Disposable, "disposable"},
+		{result.RoleAccount, "role"},
+		{result.FreeProvider, "free"},
+	} {
+		if f.set {
+			flags = append(flags, f.name)
+		}
+	}
+	// One compact array per row, in the order of the table's columns
+	data, err := json.Marshal([]interface{}{
+		result.Email, string(result.Status), result.SubStatus, result.ConfidenceScore,
+		result.Domain, result.MXHost, result.MailboxProvider, strings.Join(flags, ", "),
+		result.LatencyMs, result.Reason,
+	})
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		return w.err
+	}
+	if w.count > 0 {
+		w.buf.WriteString(",\n") //nolint:errcheck
+	}
+	if _, err := w.buf.Write(data); err != nil {
+		w.err = fmt.Errorf("failed to buffer report row: %w", err)
+		return w.err
+	}
+	w.count++
+	return nil
+}
+
+// Flush is a no-op: the report is rendered whole, on Close
+func (w *HTMLWriter) Flush() error {
+	return nil
+}
+
+// Close renders the report
+func (w *HTMLWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.summary.Finish()
+	err := w.err
+	if err == nil {
+		err = w.render()
+	}
+	w.rows.Close()
+	os.Remove(w.rows.Name())
+	if w.file != os.Stdout {
+		if cerr := w.file.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+func (w *HTMLWriter) render() error {
+	if err := w.buf.Flush(); err != nil {
+		return err
+	}
+	if _, err := w.rows.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	page := newHTMLPage(w.stats.Report(htmlTop), w.summary, w.settings)
+	out := bufio.NewWriter(w.file)
+	if err := htmlTemplates.ExecuteTemplate(out, "head", page); err != nil {
+		return err
+	}
+	// encoding/json escapes <, > and &, so rows cannot close the script
+	out.WriteString(`<script type="application/json" id="rows">[` + "\n")
+	if _, err := io.Copy(out, w.rows); err != nil {
+		return err
+	}
+	out.WriteString("\n]</script>\n")
+	if err := htmlTemplates.ExecuteTemplate(out, "tail", page); err != nil {
+		return err
+	}
+	return out.Flush()
+}
+
+// htmlStatus is one line of the status breakdown
+type htmlStatus struct {
+	Name  string
+	Count int
+	Share float64
+}
+
+// htmlPage is the data the report template renders
+type htmlPage struct {
+	Report    *report.Report
+	Statuses  []htmlStatus
+	Settings  []Setting
+	Started   time.Time
+	Finished  time.Time
+	Duration  time.Duration
+	Rate      float64
+	Generated time.Time
+	Top       int
+	MinRate   int
+}
+
+func newHTMLPage(r *report.Report, s *Summary, settings []Setting) *htmlPage {
+	statuses := []htmlStatus{
+		{Name: string(verifier.StatusValid), Count: r.Valid},
+		{Name: string(verifier.StatusInvalid), Count: r.Invalid},
+		{Name: string(verifier.StatusRisky), Count: r.Risky},
+		{Name: string(verifier.StatusUnknown), Count: r.Unknown},
+		{Name: string(verifier.StatusError), Count: r.Errors},
+	}
+	for i := range statuses {
+		statuses[i].Share = r.Share(statuses[i].Count)
+	}
+	return &htmlPage{
+		Report:    r,
+		Statuses:  statuses,
+		Settings:  settings,
+		Started:   s.Started,
+		Finished:  s.Finished,
+		Duration:  s.Duration().Round(time.Second),
+		Rate:      s.Rate(),
+		Generated: time.Now(),
+		Top:       htmlTop,
+		MinRate:   report.MinRateResults,
+	}
+}
+
+var htmlTemplates = template.Must(template.New("report").Funcs(template.FuncMap{
+	"pct": func(f float64) string {
+		return fmt.Sprintf("%.1f%%", f*100)
+	},
+	"share": func(n, total int) string {
+		if total == 0 {
+			return "0.0%"
+		}
+		return fmt.Sprintf("%.1f%%", float64(n)*100/float64(total))
+	},
+	"when": func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return t.Format("2006-01-02 15:04:05 MST")
+	},
+}).Parse(htmlTemplate))
+
+const htmlTemplate = `{{define "head"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Email verification report - {{when .Generated}}</title>
+<style>
+:root { --valid: #C6EFCE; --invalid: #FFC7CE; --risky: #FFEB9C; --unknown: #EDEDED; --error: #E4DFEC; --line: #d0d7de; --muted: #57606a; }
+* { box-sizing: border-box; }
+body { font: 14px/1.45 -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #1f2328; margin: 0; padding: 24px; background: #f6f8fa; }
+h1 { font-size: 22px; margin: 0 0 4px; }
+h2 { font-size: 16px; margin: 0 0 12px; }
+.muted { color: var(--muted); }
+.cards { display: flex; flex-wrap: wrap; gap: 12px; margin: 20px 0; }
+.card { background: #fff; border: 1px solid var(--line); border-radius: 6px; padding: 12px 16px; min-width: 130px; }
+.card b { display: block; font-size: 22px; }
+.card.valid { border-left: 6px solid var(--valid); } .card.invalid { border-left: 6px solid var(--invalid); }
+.card.risky { border-left: 6px solid var(--risky); } .card.unknown { border-left: 6px solid var(--unknown); }
+.card.error { border-left: 6px solid var(--error); }
+.grid { display: grid; grid-template-columns: repeat(auto-fit, minmax(420px, 1fr)); gap: 16px; }
+section { background: #fff; border: 1px solid var(--line); border-radius: 6px; padding: 16px; margin-bottom: 16px; overflow-x: auto; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: 4px 8px; border-bottom: 1px solid #eaeef2; vertical-align: top; }
+th { font-weight: 600; white-space: nowrap; }
+td.n, th.n { text-align: right; font-variant-numeric: tabular-nums; white-space: nowrap; }
+.bar { background: #eaeef2; border-radius: 3px; height: 10px; min-width: 120px; }
+.bar span { display: block; height: 10px; border-radius: 3px; background: #54aeff; }
+.stack { display: flex; height: 22px; border-radius: 4px; overflow: hidden; margin-bottom: 8px; }
+.valid-bg { background: var(--valid); } .invalid-bg { background: var(--invalid); } .risky-bg { background: var(--risky); }
+.unknown-bg { background: var(--unknown); } .error-bg { background: var(--error); }
+#results td { font-size: 13px; }
+#results th { cursor: pointer; user-select: none; }
+#results tr.valid td:nth-child(2) { background: var(--valid); } #results tr.invalid td:nth-child(2) { background: var(--invalid); }
+#results tr.risky td:nth-child(2) { background: var(--risky); } #results tr.unknown td:nth-child(2) { background: var(--unknown); }
+#results tr.error td:nth-child(2) { background: var(--error); }
+.controls { display: flex; flex-wrap: wrap; gap: 8px; align-items: center; margin-bottom: 8px; }
+.controls input { flex: 1; min-width: 220px; padding: 6px 8px; border: 1px solid var(--line); border-radius: 6px; }
+.controls select, .controls button { padding: 5px 8px; border: 1px solid var(--line); border-radius: 6px; background: #fff; }
+</style>
+</head>
+<body>
+<h1>Email verification report</h1>
+<div class="muted">Generated {{when .Generated}}</div>
+
+<div class="cards">
+<div class="card"><span class="muted">Total verified</span><b>{{.Report.Total}}</b></div>
+{{range .Statuses}}<div class="card {{.Name}}"><span class="muted">{{.Name}}</span><b>{{.Count}}</b>{{pct .Share}}</div>
+{{end}}<div class="card"><span class="muted">Catch-all</span><b>{{.Report.CatchAll}}</b>{{share .Report.CatchAll .Report.Total}}</div>
+<div class="card"><span class="muted">Disposable</span><b>{{.Report.Disposable}}</b>{{share .Report.Disposable .Report.Total}}</div>
+<div class="card"><span class="muted">Role accounts</span><b>{{.Report.RoleAccount}}</b>{{share .Report.RoleAccount .Report.Total}}</div>
+<div class="card"><span class="muted">Free providers</span><b>{{.Report.FreeProvider}}</b>{{share .Report.FreeProvider .Report.Total}}</div>
+</div>
+
+<div class="grid">
+<section>
+<h2>Run</h2>
+<table>
+<tr><th>Started</th><td>{{when .Started}}</td></tr>
+<tr><th>Finished</th><td>{{when .Finished}}</td></tr>
+<tr><th>Duration</th><td>{{.Duration}}</td></tr>
+<tr><th>Rate</th><td>{{printf "%.2f" .Rate}} emails/sec</td></tr>
+{{range .Settings}}{{if .Value}}<tr><th>{{.Name}}</th><td>{{.Value}}</td></tr>
+{{end}}{{end}}</table>
+</section>
+
+<section>
+<h2>Status</h2>
+<div class="stack">{{range .Statuses}}{{if .Count}}<span class="{{.Name}}-bg" style="width: {{pct .Share}}" title="{{.Name}}: {{.Count}}"></span>{{end}}{{end}}</div>
+<table>
+<tr><th>Status</th><th class="n">Count</th><th class="n">Share</th></tr>
+{{range .Statuses}}<tr><td>{{.Name}}</td><td class="n">{{.Count}}</td><td class="n">{{pct .Share}}</td></tr>
+{{end}}</table>
+{{with .Report.SubStatuses}}<h2 style="margin-top: 16px">Sub-status</h2>
+<table>
+<tr><th>Sub-status</th><th class="n">Count</th><th class="n">Share</th></tr>
+{{range .}}<tr><td>{{.Name}}</td><td class="n">{{.Count}}</td><td class="n">{{pct .Share}}</td></tr>
+{{end}}</table>{{end}}
+</section>
+
+<section>
+<h2>Top domains by volume</h2>
+<table>
+<tr><th>Domain</th><th class="n">Total</th><th class="n">Valid</th><th class="n">Invalid</th><th class="n">Risky</th><th class="n">Unknown</th><th></th></tr>
+{{range .Report.Domains}}<tr><td>{{.Name}}</td><td class="n">{{.Total}}</td><td class="n">{{.Valid}}</td><td class="n">{{.Invalid}}</td><td class="n">{{.Risky}}</td><td class="n">{{.Unknown}}</td>
+<td><div class="bar"><span style="width: {{share .Total $.Report.Total}}"></span></div></td></tr>
+{{end}}</table>
+</section>
+
+<section>
+<h2>Top domains by invalid rate</h2>
+{{if .Report.InvalidDomains}}<table>
+<tr><th>Domain</th><th class="n">Total</th><th class="n">Invalid</th><th class="n">Rate</th><th></th></tr>
+{{range .Report.InvalidDomains}}<tr><td>{{.Name}}</td><td class="n">{{.Total}}</td><td class="n">{{.Invalid}}</td><td class="n">{{pct .InvalidRate}}</td>
+<td><div class="bar"><span class="invalid-bg" style="width: {{pct .InvalidRate}}"></span></div></td></tr>
+{{end}}</table>
+<p class="muted">Domains with at least {{.MinRate}} results.</p>
+{{else}}<p class="muted">No domain with at least {{.MinRate}} results has invalid addresses.</p>{{end}}
+</section>
+
+<section>
+<h2>Latency</h2>
+<table>
+<tr><th>Min</th><th>Median</th><th>Mean</th><th>p90</th><th>p99</th><th>Max</th></tr>
+<tr>{{with .Report.Latency}}<td>{{.Min}} ms</td><td>{{.P50}} ms</td><td>{{.Mean}} ms</td><td>{{.P90}} ms</td><td>{{.P99}} ms</td><td>{{.Max}} ms</td>{{end}}</tr>
+</table>
+<table style="margin-top: 12px">
+{{range .Report.Latency.Buckets}}<tr><td>{{.Label}}</td><td class="n">{{.Count}}</td><td class="n">{{pct .Share}}</td>
+<td><div class="bar"><span style="width: {{pct .Share}}"></span></div></td></tr>
+{{end}}</table>
+</section>
+
+{{with .Report.Reasons}}<section>
+<h2>Top reasons</h2>
+<table>
+<tr><th>Reason</th><th class="n">Count</th><th class="n">Share</th></tr>
+{{range .}}<tr><td>{{.Name}}</td><td class="n">{{.Count}}</td><td class="n">{{pct .Share}}</td></tr>
+{{end}}</table>
+</section>{{end}}
+</div>
+
+<section>
+<h2>Results</h2>
+<div class="controls">
+<input id="search" type="search" placeholder="Search address, domain, MX, reason...">
+<select id="status"><option value="">All statuses</option>{{range .Statuses}}<option>{{.Name}}</option>{{end}}</select>
+<button id="prev">&larr;</button><span id="page" class="muted"></span><button id="next">&rarr;</button>
+</div>
+<table id="results">
+<thead><tr><th>Email</th><th>Status</th><th>Sub-status</th><th class="n">Score</th><th>Domain</th><th>MX host</th><th>Provider</th><th>Flags</th><th class="n">Latency</th><th>Reason</th></tr></thead>
+<tbody></tbody>
+</table>
+</section>
+{{end}}
+
+{{define "tail"}}<script>
+(function () {
+  var rows = JSON.parse(document.getElementById("rows").textContent);
+  var pageSize = 100, page = 0, sortCol = -1, sortDir = 1, shown = rows;
+  var body = document.querySelector("#results tbody");
+  var search = document.getElementById("search"), status = document.getElementById("status");
+
+  function filter() {
+    var q = search.value.trim().toLowerCase(), s = status.value;
+    shown = rows.filter(function (r) {
+      if (s && r[1] !== s) return false;
+      if (!q) return true;
+      return [r[0], r[2], r[4], r[5], r[6], r[7], r[9]].join(" ").toLowerCase().indexOf(q) >= 0;
+    });
+    if (sortCol >= 0) {
+      shown.sort(function (a, b) {
+        var x = a[sortCol], y = b[sortCol];
+        return (x < y ? -1 : x > y ? 1 : 0) * sortDir;
+      });
+    }
+    page = 0;
+    render();
+  }
+
+  function render() {
+    var pages = Math.max(1, Math.ceil(shown.length / pageSize));
+    page = Math.min(Math.max(page, 0), pages - 1);
+    var frag = document.createDocumentFragment();
+    shown.slice(page * pageSize, (page + 1) * pageSize).forEach(function (r) {
+      var tr = document.createElement("tr");
+      tr.className = r[1];
+      r.forEach(function (v, i) {
+        var td = document.createElement("td");
+        td.textContent = i === 8 ? v + " ms" : v;
+        if (i === 3 || i === 8) td.className = "n";
+        tr.appendChild(td);
+      });
+      frag.appendChild(tr);
+    });
+    body.replaceChildren(frag);
+    document.getElementById("page").textContent =
+      shown.length + " of " + rows.length + " results, page " + (page + 1) + " of " + pages;
+  }
+
+  document.querySelectorAll("#results th").forEach(function (th, i) {
+    th.addEventListener("click", function () {
+      sortDir = sortCol === i ? -sortDir : 1;
+      sortCol = i;
+      filter();
+    });
+  });
+  search.addEventListener("input", filter);
+  status.addEventListener("change", filter);
+  document.getElementById("prev").addEventListener("click", function () { page--; render(); });
+  document.getElementById("next").addEventListener("click", function () { page++; render(); });
+  render();
+})();
+</script>
+</body>
+</html>
+{{end}}`
@@ -0,0 +1,162 @@
+package output
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/nephila016/emailchecker/internal/verifier"
+)
+
+// Bucket is a named subset of results written to its own file
+type Bucket struct {
+	Name   string
+	Filter *Filter
+}
+
+// defaultBuckets are the buckets written by a split unless disabled: one per
+// status plus the disposable and role subsets
+var defaultBuckets = [][2]string{
+	{"valid", "status=valid"},
+	{"invalid", "status=invalid"},
+	{"risky", "status=risky"},
+	{"unknown", "status=unknown"},
+	{"error", "status=error"},
+	{"disposable", "disposable"},
+	{"role", "role"},
+}
+
+var bucketNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// DefaultBuckets returns the built-in status, disposable and role buckets
+func DefaultBuckets() []Bucket {
+	buckets := make([]Bucket, 0, len(defaultBuckets))
+	for _, b := range defaultBuckets {
+		f, _ := ParseFilter(b[1])
+		buckets = append(buckets, Bucket{Name: b[0], Filter: f})
+	}
+	return buckets
+}
+
+// ParseBucket parses a "name=filter" bucket definition, e.g.
+// "clean=status=valid && !role"
+func ParseBucket(def string) (Bucket, error) {
+	name, expr, ok := strings.Cut(def, "=")
+	if !ok {
+		return Bucket{}, fmt.Errorf("invalid bucket %q (use name=filter)", def)
+	}
+	return NewBucket(strings.TrimSpace(name), expr)
+}
+
+// NewBucket creates a bucket from a name and a filter expression
+func NewBucket(name, expr string) (Bucket, error) {
+	if !bucketNamePattern.MatchString(name) {
+		return Bucket{}, fmt.Errorf("invalid bucket name %q (letters, digits, _ . - only)", name)
+	}
+	f, err := ParseFilter(expr)
+	if err != nil {
+		return Bucket{}, fmt.Errorf("bucket %s: %w", name, err)
+	}
+	return Bucket{Name: name, Filter: f}, nil
+}
+
+// FilterWriter passes on only the results matching a filter
+type FilterWriter struct {
+	filter *Filter
+	writer Writer
+}
+
+func NewFilterWriter(filter *Filter, w Writer) *FilterWriter {
+	return &FilterWriter{filter: filter, writer: w}
+}
+
+func (w *FilterWriter) Write(result *verifier.Result) error {
+	if !w.filter.Match(result) {
+		return nil
+	}
+	return w.writer.Write(result)
+}
+
+func (w *FilterWriter) Flush() error {
+	return w.writer.Flush()
+}
+
+func (w *FilterWriter) Close() error {
+	return w.writer.Close()
+}
+
+// NewSplitWriter writes every bucket to dir/<bucket>.<ext>, once per
+// format, with the fields chosen by opts. A result lands in every bucket it
+// matches. Text files list the bucket's addresses, whatever their status.
+func NewSplitWriter(dir string, formats []Format, opts Options, buckets []Bucket) (*MultiWriter, error) {
+	if len(buckets) == 0 {
+		return nil, fmt.Errorf("no buckets to split into")
+	}
+	for _, format := range formats {
+		if _, err := newLayout(format, opts); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create split directory: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var writers []Writer
+	closeAll := func() {
+		for _, w := range writers {
+			w.Close()
+		}
+	}
+	for _, b := range buckets {
+		if seen[b.Name] {
+			closeAll()
+			return nil, fmt.Errorf("duplicate bucket %q", b.Name)
+		}
+		seen[b.Name] = true
+
+		for _, format := range formats {
+			filename := filepath.Join(dir, b.Name+"."+string(format))
+			var w Writer
+			var err error
+			if format == FormatTXT {
+				l, _ := newLayout(format, opts)
+				w, err = newListWriter(filename, l)
+			} else {
+				w, err = NewWriterWithOptions(filename, format, opts)
+			}
+			if err != nil {
+				closeAll()
+				return nil, err
+			}
+			writers = append(writers, NewFilterWriter(b.Filter, w))
+		}
+	}
+	return NewMultiWriter(writers...), nil
+}
+
+// ParseFormats parses a comma-separated list of output formats
+func ParseFormats(list string) ([]Format, error) {
+	var formats []Format
+	seen := make(map[Format]bool)
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		f, err := ParseFormat(name)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[f] {
+			seen[f] = true
+			formats = append(formats, f)
+		}
+	}
+	if len(formats) == 0 {
+		return nil, fmt.Errorf("no output formats given")
+	}
+	return formats, nil
+}
@@ -0,0 +1,363 @@
+package output
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nephila016/emailchecker/internal/verifier"
+
+	// Pure Go SQLite driver, registered as "sqlite"
+	_ "modernc.org/sqlite"
+)
+
+// Setting is one run setting recorded by writers that describe the run,
+// such as the SQLite runs table
+type Setting struct {
+	Name  string
+	Value string
+}
+
+const (
+	// sqliteBatchSize is how many results are written per transaction
+	sqliteBatchSize = 500
+	// sqliteCommitInterval caps how long written results wait for a commit
+	sqliteCommitInterval = 2 * time.Second
+	// sqliteSchemaVersion is stored in PRAGMA user_version
+	sqliteSchemaVersion = 1
+)
+
+// sqliteSchema creates the tables. results keeps one row per address
+// (case-insensitive); a rerun updates it and points run_id at the new run.
+// The full result is kept as JSON in the result column.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	started_at  TEXT NOT NULL,
+	finished_at TEXT,
+	settings    TEXT NOT NULL DEFAULT '{}',
+	total       INTEGER NOT NULL DEFAULT 0,
+	valid       INTEGER NOT NULL DEFAULT 0,
+	invalid     INTEGER NOT NULL DEFAULT 0,
+	unknown     INTEGER NOT NULL DEFAULT 0,
+	risky       INTEGER NOT NULL DEFAULT 0,
+	errors      INTEGER NOT NULL DEFAULT 0
+);
+CREATE TABLE IF NOT EXISTS results (
+	email                 TEXT NOT NULL PRIMARY KEY COLLATE NOCASE,
+	run_id                INTEGER NOT NULL REFERENCES runs(id),
+	row                   INTEGER,
+	domain                TEXT NOT NULL DEFAULT '',
+	status                TEXT NOT NULL,
+	sub_status            TEXT NOT NULL DEFAULT '',
+	valid                 INTEGER NOT NULL,
+	status_code           INTEGER NOT NULL DEFAULT 0,
+	reason                TEXT NOT NULL DEFAULT '',
+	confidence_score      INTEGER NOT NULL DEFAULT 0,
+	disposable            INTEGER NOT NULL DEFAULT 0,
+	role_account          INTEGER NOT NULL DEFAULT 0,
+	free_provider         INTEGER NOT NULL DEFAULT 0,
+	catch_all             INTEGER NOT NULL DEFAULT 0,
+	catch_all_probability REAL NOT NULL DEFAULT 0,
+	mailbox_provider      TEXT NOT NULL DEFAULT '',
+	mx_host               TEXT NOT NULL DEFAULT '',
+	smtp_response         TEXT NOT NULL DEFAULT '',
+	tls_used              INTEGER NOT NULL DEFAULT 0,
+	syntax_error          TEXT NOT NULL DEFAULT '',
+	canonical_email       TEXT NOT NULL DEFAULT '',
+	did_you_mean          TEXT NOT NULL DEFAULT '',
+	tags                  TEXT NOT NULL DEFAULT '',
+	error                 TEXT NOT NULL DEFAULT '',
+	latency_ms            INTEGER NOT NULL DEFAULT 0,
+	verified_at           TEXT NOT NULL,
+	result                TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS results_status ON results(status);
+CREATE INDEX IF NOT EXISTS results_domain ON results(domain);
+CREATE INDEX IF NOT EXISTS results_run ON results(run_id);
+`
+
+// sqliteColumns are the results columns in insert order
+var sqliteColumns = []string{
+	"email", "run_id", "row", "domain", "status", "sub_status", "valid",
+	"status_code", "reason", "confidence_score", "disposable", "role_account",
+	"free_provider", "catch_all", "catch_all_probability", "mailbox_provider",
+	"mx_host", "smtp_response", "tls_used", "syntax_error", "canonical_email",
+	"did_you_mean", "tags", "error", "latency_ms", "verified_at", "result",
+}
+
+// SQLiteWriter writes results to a SQLite database. Results are committed
+// in batches, and at least every couple of seconds; the run's counts in the
+// runs table are updated with each commit.
+type SQLiteWriter struct {
+	db         *sql.DB
+	upsert     string
+	runID      int64
+	started    time.Time
+	mu         sync.Mutex
+	pending    []*verifier.Result
+	stored     map[verifier.Status]int // the run's rows in results, by status
+	lastCommit time.Time
+	err        error
+}
+
+// NewSQLiteWriter opens (or creates) the database at filename and starts a
+// run with the given settings
+func NewSQLiteWriter(filename string, settings []Setting) (*SQLiteWriter, error) {
+	if filename == "-" {
+		return nil, fmt.Errorf("SQLite output needs a file name, not stdout")
+	}
+	db, err := sql.Open("sqlite", filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	// One connection keeps the pragmas in force and writes serialized
+	db.SetMaxOpenConns(1)
+
+	w := &SQLiteWriter{
+		db:         db,
+		upsert:     sqliteUpsert(),
+		started:    time.Now(),
+		stored:     make(map[verifier.Status]int),
+		lastCommit: time.Now(),
+	}
+	if err := w.init(settings); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to set up database %s: %w", filename, err)
+	}
+	return w, nil
+}
+
+// init creates the schema and the run row
+func (w *SQLiteWriter) init(settings []Setting) error {
+	for _, pragma := range []string{
+		"PRAGMA journal_mode = WAL",
+		"PRAGMA synchronous = NORMAL",
+		"PRAGMA busy_timeout = 5000",
+	} {
+		if _, err := w.db.Exec(pragma); err != nil {
+			return err
+		}
+	}
+
+	var version int
+	if err := w.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	if version > sqliteSchemaVersion {
+		return fmt.Errorf("database schema version %d is newer than this tool supports (%d)", version, sqliteSchemaVersion)
+	}
+	if _, err := w.db.Exec(sqliteSchema); err != nil {
+		return err
+	}
+	if _, err := w.db.Exec(fmt.Sprintf("PRAGMA user_version = %d", sqliteSchemaVersion)); err != nil {
+		return err
+	}
+
+	res, err := w.db.Exec("INSERT INTO runs (started_at, settings) VALUES (?, ?)",
+		sqliteTime(w.started), settingsJSON(settings))
+	if err != nil {
+		return err
+	}
+	w.runID, err = res.LastInsertId()
+	return err
+}
+
+// sqliteUpsert builds the insert that replaces an existing row for the
+// same address
+func sqliteUpsert() string {
+	updates := make([]string, 0, len(sqliteColumns)-1)
+	for _, c := range sqliteColumns[1:] {
+		updates = append(updates, c+" = excluded."+c)
+	}
+	return fmt.Sprintf("INSERT INTO results (%s) VALUES (%s) ON CONFLICT(email) DO UPDATE SET %s",
+		strings.Join(sqliteColumns, ", "),
+		strings.TrimSuffix(strings.Repeat("?, ", len(sqliteColumns)), ", "),
+		strings.Join(updates, ", "))
+}
+
+// settingsJSON encodes settings as a JSON object, in order
+func settingsJSON(settings []Setting) string {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, s := range settings {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(s.Name)
+		value, _ := json.Marshal(s.Value)
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.String()
+}
+
+// sqliteTime formats a time so SQLite's date functions understand it and
+// text order is time order
+func sqliteTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// Write queues result for the next commit. Results without an address
+// (blank input rows) are skipped: the address is the table's key.
+func (w *SQLiteWriter) Write(result *verifier.Result) error {
+	if result.Email == "" {
+		return nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		return w.err
+	}
+	w.pending = append(w.pending, result)
+	if len(w.pending) >= sqliteBatchSize {
+		return w.commit()
+	}
+	return nil
+}
+
+// Flush commits pending results once a batch is full or the commit
+// interval has passed, so calling it after every result stays cheap
+func (w *SQLiteWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		return w.err
+	}
+	if len(w.pending) > 0 && time.Since(w.lastCommit) >= sqliteCommitInterval {
+		return w.commit()
+	}
+	return nil
+}
+
+// commit writes pending results and the run's counts in one transaction
+func (w *SQLiteWriter) commit() error {
+	w.lastCommit = time.Now()
+	if err := w.writeBatch(); err != nil {
+		w.err = fmt.Errorf("failed to write results: %w", err)
+		return w.err
+	}
+	w.pending = w.pending[:0]
+	return nil
+}
+
+// writeBatch upserts the pending results. The run's counts are those of
+// its rows in the results table, so a result that replaces a row written
+// earlier in the run takes that row's place in the counts.
+func (w *SQLiteWriter) writeBatch() error {
+	tx, err := w.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	stmt, err := tx.Prepare(w.upsert)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	previous, err := tx.Prepare(`SELECT run_id, status FROM results WHERE email = ?`)
+	if err != nil {
+		return err
+	}
+	defer previous.Close()
+
+	counts := make(map[verifier.Status]int, len(w.stored))
+	for status, n := range w.stored {
+		counts[status] = n
+	}
+
+	for _, r := range w.pending {
+		var runID int64
+		var status string
+		switch err := previous.QueryRow(r.Email).Scan(&runID, &status); {
+		case err == sql.ErrNoRows:
+		case err != nil:
+			return fmt.Errorf("%s: %w", r.Email, err)
+		case runID == w.runID:
+			counts[verifier.Status(status)]--
+		}
+
+		var row interface{}
+		if r.Row > 0 {
+			row = r.Row
+		}
+		data, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		if _, err := stmt.Exec(
+			r.Email, w.runID, row, r.Domain, string(r.Status), r.SubStatus, r.Valid,
+			r.StatusCode, r.Reason, r.ConfidenceScore, r.Disposable, r.RoleAccount,
+			r.FreeProvider, r.CatchAll, r.CatchAllProbability, r.MailboxProvider,
+			r.MXHost, r.SMTPResponse, r.TLSUsed, r.SyntaxError, r.CanonicalEmail,
+			r.DidYouMean, strings.Join(r.Tags, ";"), r.Error, r.LatencyMs,
+			sqliteTime(r.VerifiedAt), string(data),
+		); err != nil {
+			return fmt.Errorf("%s: %w", r.Email, err)
+		}
+		counts[r.Status]++
+	}
+
+	if err := w.updateRun(tx, counts, nil); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	w.stored = counts
+	return nil
+}
+
+// updateRun stores the run's counts, and its end time once finished
+func (w *SQLiteWriter) updateRun(tx *sql.Tx, counts map[verifier.Status]int, finishedAt interface{}) error {
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	_, err := tx.Exec(`UPDATE runs SET total = ?, valid = ?, invalid = ?, unknown = ?, risky = ?, errors = ?,
+		finished_at = COALESCE(?, finished_at) WHERE id = ?`,
+		total, counts[verifier.StatusValid], counts[verifier.StatusInvalid], counts[verifier.StatusUnknown],
+		counts[verifier.StatusRisky], counts[verifier.StatusError], finishedAt, w.runID)
+	return err
+}
+
+// Close commits the remaining results, marks the run finished and closes
+// the database. The run is marked finished even when a commit failed, with
+// the counts of the results stored before the failure.
+func (w *SQLiteWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	finishedAt := sqliteTime(time.Now())
+	err := w.err
+	if err == nil && len(w.pending) > 0 {
+		err = w.commit()
+	}
+
+	tx, terr := w.db.Begin()
+	if terr == nil {
+		if terr = w.updateRun(tx, w.stored, finishedAt); terr == nil {
+			terr = tx.Commit()
+		} else {
+			tx.Rollback() //nolint:errcheck
+		}
+	}
+	if err == nil {
+		err = terr
+	}
+	if cerr := w.db.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
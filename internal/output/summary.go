@@ -0,0 +1,75 @@
+package output
+
+import (
+	"sync"
+	"time"
+
+	"github.com/nephila016/emailchecker/internal/verifier"
+)
+
+// Summary tallies results by status, the figures a bulk run prints at the
+// end. Writers that render a summary of their own keep one.
+type Summary struct {
+	mu sync.Mutex
+
+	Total   int
+	Valid   int
+	Invalid int
+	Unknown int
+	Risky   int
+	Errors  int
+
+	Started  time.Time
+	Finished time.Time
+}
+
+// NewSummary starts a summary at the current time
+func NewSummary() *Summary {
+	return &Summary{Started: time.Now()}
+}
+
+// Add counts one result
+func (s *Summary) Add(result *verifier.Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Total++
+	switch result.Status {
+	case verifier.StatusValid:
+		s.Valid++
+	case verifier.StatusInvalid:
+		s.Invalid++
+	case verifier.StatusUnknown:
+		s.Unknown++
+	case verifier.StatusRisky:
+		s.Risky++
+	case verifier.StatusError:
+		s.Errors++
+	}
+}
+
+// Finish records the end of the run, once
+func (s *Summary) Finish() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Finished.IsZero() {
+		s.Finished = time.Now()
+	}
+}
+
+// Duration is the time from start to finish, or until now while running
+func (s *Summary) Duration() time.Duration {
+	if s.Finished.IsZero() {
+		return time.Since(s.Started)
+	}
+	return s.Finished.Sub(s.Started)
+}
+
+// Rate is the number of results per second
+func (s *Summary) Rate() float64 {
+	secs := s.Duration().Seconds()
+	if secs <= 0 {
+		return 0
+	}
+	return float64(s.Total) / secs
+}
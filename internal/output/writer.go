@@ -1,8 +1,8 @@
 package output
 
 import (
-	"encoding/csv"
-	"encoding/json"
+	"bufio"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -24,10 +24,13 @@ type Writer interface {
 type Format string
 
 const (
-	FormatJSON  Format = "json"
-	FormatCSV   Format = "csv"
-	FormatJSONL Format = "jsonl"
-	FormatTXT   Format = "txt"
+	FormatJSON   Format = "json"
+	FormatCSV    Format = "csv"
+	FormatJSONL  Format = "jsonl"
+	FormatTXT    Format = "txt"
+	FormatXLSX   Format = "xlsx"
+	FormatSQLite Format = "sqlite"
+	FormatHTML   Format = "html"
 )
 
 // DetectFormat detects output format from filename
@@ -40,92 +43,223 @@ func DetectFormat(filename string) Format {
 		return FormatCSV
 	case ".jsonl", ".ndjson":
 		return FormatJSONL
+	case ".xlsx":
+		return FormatXLSX
+	case ".db", ".sqlite", ".sqlite3":
+		return FormatSQLite
+	case ".html", ".htm":
+		return FormatHTML
 	default:
 		return FormatTXT
 	}
 }
 
-// NewWriter creates a writer for the given format and file
+// ParseFormat validates a format name given on the command line
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(name)); f {
+	case FormatJSON, FormatCSV, FormatJSONL, FormatTXT, FormatXLSX, FormatSQLite, FormatHTML:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown output format %q (use csv, json, jsonl, txt, xlsx, sqlite or html)", name)
+}
+
+// NewWriter creates a writer for the given format and file. A filename of
+// "-" streams to stdout.
 func NewWriter(filename string, format Format) (Writer, error) {
-	file, err := os.Create(filename)
+	return NewWriterWithColumns(filename, format, nil)
+}
+
+// NewWriterWithColumns creates a writer that carries the original input
+// columns through to the output. CSV writes them ahead of the verification
+// fields; JSON formats include them as the "input" object.
+func NewWriterWithColumns(filename string, format Format, inputColumns []string) (Writer, error) {
+	return NewWriterWithOptions(filename, format, Options{InputColumns: inputColumns})
+}
+
+// NewWriterWithOptions creates a writer with the fields and formatting
+// chosen by opts
+func NewWriterWithOptions(filename string, format Format, opts Options) (Writer, error) {
+	// SQLite has a fixed schema and updates an existing database in place
+	if format == FormatSQLite {
+		return NewSQLiteWriter(filename, opts.Settings)
+	}
+
+	l, err := newLayout(format, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create output file: %w", err)
+		return nil, err
+	}
+
+	file := os.Stdout
+	if filename != "-" {
+		if file, err = os.Create(filename); err != nil {
+			return nil, fmt.Errorf("failed to create output file: %w", err)
+		}
 	}
 
 	switch format {
 	case FormatJSON:
-		return NewJSONWriter(file), nil
+		return newJSONWriter(file, l), nil
 	case FormatCSV:
-		return NewCSVWriter(file), nil
+		return newCSVWriter(file, l), nil
 	case FormatJSONL:
-		return NewJSONLWriter(file), nil
+		return newJSONLWriter(file, l), nil
+	case FormatXLSX:
+		w, err := newXLSXWriter(file, l)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		return w, nil
+	case FormatHTML:
+		w, err := NewHTMLWriter(file, opts.Settings)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		return w, nil
 	default:
-		return NewTXTWriter(file), nil
+		return &TXTWriter{file: file, layout: l}, nil
 	}
 }
 
-// JSONWriter writes results as JSON array
+// defaultLayout returns the layout of a format with no options set
+func defaultLayout(format Format, inputColumns ...string) *layout {
+	l, _ := newLayout(format, Options{InputColumns: inputColumns})
+	return l
+}
+
+// jsonArrayEnd closes the array written by JSONWriter
+const jsonArrayEnd = "\n]\n"
+
+// JSONWriter streams results as a JSON array, one element per line. Results
+// are not kept in memory. On a regular file every Flush also writes the
+// closing bracket and then steps back over it, so the file is valid JSON
+// after each flush; the next element overwrites the bracket. If a run is
+// killed mid-write, only the last line can be incomplete.
 type JSONWriter struct {
-	file    *os.File
-	results []*verifier.Result
-	mu      sync.Mutex
+	file     *os.File
+	buf      *bufio.Writer
+	layout   *layout
+	mu       sync.Mutex
+	count    int
+	seekable bool
+	err      error
 }
 
 func NewJSONWriter(file *os.File) *JSONWriter {
-	return &JSONWriter{
-		file:    file,
-		results: make([]*verifier.Result, 0),
+	return newJSONWriter(file, defaultLayout(FormatJSON))
+}
+
+func newJSONWriter(file *os.File, l *layout) *JSONWriter {
+	w := &JSONWriter{
+		file:   file,
+		buf:    bufio.NewWriter(file),
+		layout: l,
 	}
+	if info, err := file.Stat(); err == nil && info.Mode().IsRegular() {
+		_, err := file.Seek(0, io.SeekCurrent)
+		w.seekable = err == nil
+	}
+	w.buf.WriteString("[\n") //nolint:errcheck
+	return w
 }
 
 func (w *JSONWriter) Write(result *verifier.Result) error {
+	data, err := w.layout.encodeJSON(result)
+	if err != nil {
+		return err
+	}
+
 	w.mu.Lock()
 	defer w.mu.Unlock()
-	w.results = append(w.results, result)
-	return nil
+
+	if w.err != nil {
+		return w.err
+	}
+	if w.count > 0 {
+		w.buf.WriteString(",\n") //nolint:errcheck
+	}
+	w.count++
+	_, err = w.buf.Write(data)
+	return err
 }
 
 func (w *JSONWriter) Flush() error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
+	return w.flush()
+}
 
-	w.file.Seek(0, 0)
-	w.file.Truncate(0)
+// flush writes buffered elements and, on a regular file, a closing bracket
+// that the next write overwrites
+func (w *JSONWriter) flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	if err := w.buf.Flush(); err != nil {
+		w.err = err
+		return err
+	}
+	if !w.seekable {
+		return nil
+	}
 
-	encoder := json.NewEncoder(w.file)
-	encoder.SetIndent("", "  ")
-	return encoder.Encode(w.results)
+	pos, err := w.file.Seek(0, io.SeekCurrent)
+	if err == nil {
+		_, err = w.file.WriteString(jsonArrayEnd)
+	}
+	if err == nil {
+		_, err = w.file.Seek(pos, io.SeekStart)
+	}
+	if err != nil {
+		w.err = fmt.Errorf("failed to close JSON array: %w", err)
+	}
+	return w.err
 }
 
 func (w *JSONWriter) Close() error {
-	if err := w.Flush(); err != nil {
-		return err
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	err := w.flush()
+	if err == nil && !w.seekable {
+		_, err = w.file.WriteString(jsonArrayEnd)
 	}
-	return w.file.Close()
+	if cerr := w.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
 }
 
 // JSONLWriter writes results as JSON Lines (one JSON per line)
 type JSONLWriter struct {
-	file    *os.File
-	encoder *json.Encoder
-	mu      sync.Mutex
+	file   *os.File
+	layout *layout
+	mu     sync.Mutex
 }
 
 func NewJSONLWriter(file *os.File) *JSONLWriter {
-	return &JSONLWriter{
-		file:    file,
-		encoder: json.NewEncoder(file),
-	}
+	return newJSONLWriter(file, defaultLayout(FormatJSONL))
+}
+
+func newJSONLWriter(file *os.File, l *layout) *JSONLWriter {
+	return &JSONLWriter{file: file, layout: l}
 }
 
 func (w *JSONLWriter) Write(result *verifier.Result) error {
+	data, err := w.layout.encodeJSON(result)
+	if err != nil {
+		return err
+	}
+
 	w.mu.Lock()
 	defer w.mu.Unlock()
-	return w.encoder.Encode(result)
+	_, err = w.file.Write(append(data, '\n'))
+	return err
 }
 
 func (w *JSONLWriter) Flush() error {
-	return w.file.Sync()
+	return syncFile(w.file)
 }
 
 func (w *JSONLWriter) Close() error {
@@ -135,62 +269,44 @@ func (w *JSONLWriter) Close() error {
 // CSVWriter writes results as CSV
 type CSVWriter struct {
 	file   *os.File
-	writer *csv.Writer
+	buf    *bufio.Writer
+	layout *layout
 	mu     sync.Mutex
-	header bool
 }
 
-func NewCSVWriter(file *os.File) *CSVWriter {
+// NewCSVWriter creates a CSV writer with the default fields. inputColumns
+// are original input columns written, unchanged, ahead of them.
+func NewCSVWriter(file *os.File, inputColumns ...string) *CSVWriter {
+	return newCSVWriter(file, defaultLayout(FormatCSV, inputColumns...))
+}
+
+func newCSVWriter(file *os.File, l *layout) *CSVWriter {
 	w := &CSVWriter{
 		file:   file,
-		writer: csv.NewWriter(file),
+		buf:    bufio.NewWriter(file),
+		layout: l,
 	}
 	// Write header
-	w.writer.Write([]string{
-		"email",
-		"valid",
-		"status",
-		"status_code",
-		"reason",
-		"disposable",
-		"role_account",
-		"free_provider",
-		"catch_all",
-		"mx_host",
-		"confidence_score",
-		"latency_ms",
-		"verified_at",
-	})
-	w.header = true
+	w.buf.WriteString(l.csvLine(l.headers())) //nolint:errcheck
 	return w
 }
 
 func (w *CSVWriter) Write(result *verifier.Result) error {
+	cells, err := w.layout.cells(result)
+	if err != nil {
+		return err
+	}
+
 	w.mu.Lock()
 	defer w.mu.Unlock()
-
-	return w.writer.Write([]string{
-		result.Email,
-		fmt.Sprintf("%t", result.Valid),
-		string(result.Status),
-		fmt.Sprintf("%d", result.StatusCode),
-		result.Reason,
-		fmt.Sprintf("%t", result.Disposable),
-		fmt.Sprintf("%t", result.RoleAccount),
-		fmt.Sprintf("%t", result.FreeProvider),
-		fmt.Sprintf("%t", result.CatchAll),
-		result.MXHost,
-		fmt.Sprintf("%d", result.ConfidenceScore),
-		fmt.Sprintf("%d", result.LatencyMs),
-		result.VerifiedAt.Format("2006-01-02 15:04:05"),
-	})
+	_, err = w.buf.WriteString(w.layout.csvLine(cells))
+	return err
 }
 
 func (w *CSVWriter) Flush() error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
-	w.writer.Flush()
-	return w.writer.Error()
+	return w.buf.Flush()
 }
 
 func (w *CSVWriter) Close() error {
@@ -198,36 +314,66 @@ func (w *CSVWriter) Close() error {
 	return w.file.Close()
 }
 
-// TXTWriter writes valid emails as plain text (one per line)
+// TXTWriter writes valid emails as plain text (one per line). With more
+// than one field selected, each line holds the fields separated by the
+// delimiter.
 type TXTWriter struct {
-	file *os.File
-	mu   sync.Mutex
+	file   *os.File
+	layout *layout
+	mu     sync.Mutex
+	all    bool
 }
 
 func NewTXTWriter(file *os.File) *TXTWriter {
-	return &TXTWriter{file: file}
+	return &TXTWriter{file: file, layout: defaultLayout(FormatTXT)}
+}
+
+// newListWriter creates a text writer that lists every address it is given,
+// for split buckets whose filter already chose the results
+func newListWriter(filename string, l *layout) (*TXTWriter, error) {
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file: %w", err)
+	}
+	return &TXTWriter{file: file, layout: l, all: true}, nil
 }
 
 func (w *TXTWriter) Write(result *verifier.Result) error {
-	if !result.Valid && result.Status != verifier.StatusRisky {
+	if !w.all && !result.Valid && result.Status != verifier.StatusRisky {
 		return nil // Only write valid/risky emails
 	}
+	cells, err := w.layout.cells(result)
+	if err != nil {
+		return err
+	}
 
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
-	_, err := fmt.Fprintf(w.file, "%s\n", result.Email)
+	_, err = fmt.Fprintf(w.file, "%s\n", strings.Join(cells, string(w.layout.delim)))
 	return err
 }
 
 func (w *TXTWriter) Flush() error {
-	return w.file.Sync()
+	return syncFile(w.file)
 }
 
 func (w *TXTWriter) Close() error {
 	return w.file.Close()
 }
 
+// syncFile commits a regular output file to disk. Stdout, pipes and other
+// non-regular files are left alone: they cannot be synced.
+func syncFile(file *os.File) error {
+	if file == os.Stdout {
+		return nil
+	}
+	if info, err := file.Stat(); err != nil || !info.Mode().IsRegular() {
+		return nil
+	}
+	return file.Sync()
+}
+
 // MultiWriter writes to multiple outputs
 type MultiWriter struct {
 	writers []Writer
@@ -246,22 +392,26 @@ func (w *MultiWriter) Write(result *verifier.Result) error {
 	return nil
 }
 
+// Flush flushes every writer, even after one fails
 func (w *MultiWriter) Flush() error {
+	var errs []error
 	for _, writer := range w.writers {
 		if err := writer.Flush(); err != nil {
-			return err
+			errs = append(errs, err)
 		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
+// Close closes every writer, even after one fails
 func (w *MultiWriter) Close() error {
+	var errs []error
 	for _, writer := range w.writers {
 		if err := writer.Close(); err != nil {
-			return err
+			errs = append(errs, err)
 		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
 // WriteResultsToFile writes all results to a file
@@ -0,0 +1,316 @@
+package output
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/nephila016/emailchecker/internal/verifier"
+	"github.com/xuri/excelize/v2"
+)
+
+// Sheet names of XLSX output
+const (
+	xlsxResultsSheet = "Results"
+	xlsxSummarySheet = "Summary"
+)
+
+// xlsxMaxCell is the most characters Excel allows in a cell
+const xlsxMaxCell = 32767
+
+// truncateCell cuts s to xlsxMaxCell characters, on a rune boundary
+func truncateCell(s string) string {
+	if len(s) <= xlsxMaxCell {
+		return s // never more characters than bytes
+	}
+	n := 0
+	for i := range s {
+		if n == xlsxMaxCell {
+			return s[:i]
+		}
+		n++
+	}
+	return s
+}
+
+// xlsxStatusFills are the row colors of each status, Excel's own
+// good/bad/neutral palette
+var xlsxStatusFills = map[verifier.Status]string{
+	verifier.StatusValid:   "#C6EFCE",
+	verifier.StatusInvalid: "#FFC7CE",
+	verifier.StatusRisky:   "#FFEB9C",
+	verifier.StatusUnknown: "#EDEDED",
+	verifier.StatusError:   "#E4DFEC",
+}
+
+// xlsxStyle holds the style IDs for one row color
+type xlsxStyle struct {
+	cell, date int
+}
+
+// XLSXWriter writes results to an Excel workbook: a Results sheet with
+// typed columns, rows colored by status, a frozen header and an auto-filter,
+// and a Summary sheet with the end-of-run figures. Rows are streamed to a
+// temporary file; the workbook itself is written on Close.
+type XLSXWriter struct {
+	file    *os.File
+	book    *excelize.File
+	stream  *excelize.StreamWriter
+	layout  *layout
+	summary *Summary
+	styles  map[verifier.Status]xlsxStyle
+	plain   xlsxStyle
+	mu      sync.Mutex
+	row     int
+	err     error
+}
+
+func newXLSXWriter(file *os.File, l *layout) (*XLSXWriter, error) {
+	book := excelize.NewFile()
+	w := &XLSXWriter{
+		file:    file,
+		book:    book,
+		layout:  l,
+		summary: NewSummary(),
+		styles:  make(map[verifier.Status]xlsxStyle),
+	}
+	if err := w.init(); err != nil {
+		book.Close()
+		return nil, fmt.Errorf("failed to create workbook: %w", err)
+	}
+	return w, nil
+}
+
+// init sets up the Results sheet, its styles and the header row
+func (w *XLSXWriter) init() error {
+	if err := w.book.SetSheetName("Sheet1", xlsxResultsSheet); err != nil {
+		return err
+	}
+
+	var err error
+	if w.plain, err = w.newRowStyle(""); err != nil {
+		return err
+	}
+	for status, fill := range xlsxStatusFills {
+		if w.styles[status], err = w.newRowStyle(fill); err != nil {
+			return err
+		}
+	}
+	header, err := w.book.NewStyle(&excelize.Style{
+		Font:   &excelize.Font{Bold: true},
+		Fill:   excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"#D9E1F2"}},
+		Border: []excelize.Border{{Type: "bottom", Color: "#8EA9DB", Style: 1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	if w.stream, err = w.book.NewStreamWriter(xlsxResultsSheet); err != nil {
+		return err
+	}
+	// Column widths and panes must be set before the first row
+	for i, c := range w.layout.columns {
+		width := float64(len(c.header) + 4)
+		switch {
+		case c.time:
+			width = 20
+		case width < 12:
+			width = 12
+		}
+		if c.path[0] == "email" || c.path[0] == "canonical_email" || c.path[0] == "reason" {
+			width = 32
+		}
+		if err := w.stream.SetColWidth(i+1, i+1, width); err != nil {
+			return err
+		}
+	}
+	if err := w.stream.SetPanes(&excelize.Panes{
+		Freeze:      true,
+		YSplit:      1,
+		TopLeftCell: "A2",
+		ActivePane:  "bottomLeft",
+	}); err != nil {
+		return err
+	}
+
+	cells := make([]interface{}, len(w.layout.columns))
+	for i, h := range w.layout.headers() {
+		cells[i] = excelize.Cell{StyleID: header, Value: h}
+	}
+	w.row = 1
+	return w.stream.SetRow("A1", cells)
+}
+
+// newRowStyle creates the text and date styles of a row color ("" = none)
+func (w *XLSXWriter) newRowStyle(fill string) (xlsxStyle, error) {
+	style := &excelize.Style{}
+	if fill != "" {
+		style.Fill = excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{fill}}
+	}
+	cell, err := w.book.NewStyle(style)
+	if err != nil {
+		return xlsxStyle{}, err
+	}
+	dateFormat := "yyyy-mm-dd hh:mm:ss"
+	style.CustomNumFmt = &dateFormat
+	date, err := w.book.NewStyle(style)
+	if err != nil {
+		return xlsxStyle{}, err
+	}
+	return xlsxStyle{cell: cell, date: date}, nil
+}
+
+func (w *XLSXWriter) Write(result *verifier.Result) error {
+	values, err := w.layout.typedValues(result)
+	if err != nil {
+		return err
+	}
+	w.summary.Add(result)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		return w.err
+	}
+	style, ok := w.styles[result.Status]
+	if !ok {
+		style = w.plain
+	}
+	cells := make([]interface{}, len(values))
+	for i, v := range values {
+		id := style.cell
+		switch v := v.(type) {
+		case time.Time:
+			id = style.date
+		case string:
+			values[i] = truncateCell(v)
+		}
+		cells[i] = excelize.Cell{StyleID: id, Value: values[i]}
+	}
+
+	w.row++
+	cell, _ := excelize.CoordinatesToCellName(1, w.row)
+	if err := w.stream.SetRow(cell, cells); err != nil {
+		w.err = fmt.Errorf("failed to write row %d: %w", w.row, err)
+		return w.err
+	}
+	return nil
+}
+
+// Flush is a no-op: a workbook can only be written whole, on Close
+func (w *XLSXWriter) Flush() error {
+	return nil
+}
+
+// Close adds the auto-filter and the Summary sheet and writes the workbook
+func (w *XLSXWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.summary.Finish()
+	err := w.err
+	if err == nil {
+		err = w.finish()
+	}
+	if err == nil {
+		err = w.book.Write(w.file)
+	}
+	w.book.Close()
+	if cerr := w.file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write workbook: %w", err)
+	}
+	return nil
+}
+
+func (w *XLSXWriter) finish() error {
+	last, _ := excelize.CoordinatesToCellName(len(w.layout.columns), w.row)
+	if err := w.book.AutoFilter(xlsxResultsSheet, "A1:"+last, nil); err != nil {
+		return err
+	}
+	if err := w.stream.Flush(); err != nil {
+		return err
+	}
+	return w.writeSummary()
+}
+
+// writeSummary adds the Summary sheet, mirroring the bulk summary
+func (w *XLSXWriter) writeSummary() error {
+	if _, err := w.book.NewSheet(xlsxSummarySheet); err != nil {
+		return err
+	}
+	s := w.summary
+	percent := func(n int) interface{} {
+		if s.Total == 0 {
+			return nil
+		}
+		return float64(n) / float64(s.Total)
+	}
+	rows := [][]interface{}{
+		{"Summary", nil, nil},
+		{"Total Verified", s.Total, nil},
+		{"Valid", s.Valid, percent(s.Valid)},
+		{"Invalid", s.Invalid, percent(s.Invalid)},
+		{"Unknown", s.Unknown, percent(s.Unknown)},
+		{"Risky", s.Risky, percent(s.Risky)},
+		{"Errors", s.Errors, percent(s.Errors)},
+		{nil, nil, nil},
+		{"Started", s.Started, nil},
+		{"Finished", s.Finished, nil},
+		{"Duration", s.Duration().Round(time.Second).String(), nil},
+		{"Rate (emails/sec)", s.Rate(), nil},
+	}
+	for i, row := range rows {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		if err := w.book.SetSheetRow(xlsxSummarySheet, cell, &row); err != nil {
+			return err
+		}
+	}
+
+	bold, err := w.book.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true, Size: 14}})
+	if err != nil {
+		return err
+	}
+	pct, err := w.book.NewStyle(&excelize.Style{NumFmt: 10})
+	if err != nil {
+		return err
+	}
+	dateFormat := "yyyy-mm-dd hh:mm:ss"
+	date, err := w.book.NewStyle(&excelize.Style{CustomNumFmt: &dateFormat})
+	if err != nil {
+		return err
+	}
+	rate, err := w.book.NewStyle(&excelize.Style{NumFmt: 2})
+	if err != nil {
+		return err
+	}
+	for _, st := range []struct {
+		from, to string
+		style    int
+	}{
+		{"A1", "A1", bold},
+		{"C3", "C7", pct},
+		{"B9", "B10", date},
+		{"B12", "B12", rate},
+	} {
+		if err := w.book.SetCellStyle(xlsxSummarySheet, st.from, st.to, st.style); err != nil {
+			return err
+		}
+	}
+	for i, status := range []verifier.Status{
+		verifier.StatusValid, verifier.StatusInvalid, verifier.StatusUnknown, verifier.StatusRisky, verifier.StatusError,
+	} {
+		cell, _ := excelize.CoordinatesToCellName(1, i+3)
+		if err := w.book.SetCellStyle(xlsxSummarySheet, cell, cell, w.styles[status].cell); err != nil {
+			return err
+		}
+	}
+	if err := w.book.SetColWidth(xlsxSummarySheet, "A", "A", 20); err != nil {
+		return err
+	}
+	return w.book.SetColWidth(xlsxSummarySheet, "B", "C", 22)
+}
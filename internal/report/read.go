@@ -0,0 +1,403 @@
+package report
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nephila016/emailchecker/internal/verifier"
+
+	// Pure Go SQLite driver, registered as "sqlite"
+	_ "modernc.org/sqlite"
+)
+
+// Format is the kind of a results file
+type Format string
+
+const (
+	FormatJSON   Format = "json"
+	FormatJSONL  Format = "jsonl"
+	FormatCSV    Format = "csv"
+	FormatSQLite Format = "sqlite"
+)
+
+// ErrTruncated is returned, after every complete result has been read, when
+// a JSON array ends early, as it does after an interrupted run
+var ErrTruncated = errors.New("file ends early (interrupted run?)")
+
+// sqliteMagic starts every SQLite database file
+const sqliteMagic = "SQLite format 3\x00"
+
+// ReadOptions control how a results file is read
+type ReadOptions struct {
+	// Formats limits the accepted formats; any when empty
+	Formats []Format
+	// Delimiter separates CSV columns. When 0 it is detected from the
+	// header: comma, semicolon, tab or pipe.
+	Delimiter rune
+}
+
+// ReadFile calls fn for every result in a results file written by bulk or
+// check: a JSON array, JSON Lines, CSV or a SQLite database. The format is
+// detected from the content; "-" reads stdin.
+func ReadFile(filename string, opts ReadOptions, fn func(*verifier.Result) error) error {
+	var file *os.File
+	if filename == "-" {
+		file = os.Stdin
+	} else {
+		var err error
+		if file, err = os.Open(filename); err != nil {
+			return fmt.Errorf("failed to open results file: %w", err)
+		}
+		defer file.Close()
+	}
+
+	reader := bufio.NewReaderSize(file, 64*1024)
+	format, err := detect(reader)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	if len(opts.Formats) > 0 && !hasFormat(opts.Formats, format) {
+		return fmt.Errorf("%s is a %s results file; expected %s", filename, format, joinFormats(opts.Formats))
+	}
+
+	switch format {
+	case FormatSQLite:
+		if filename == "-" {
+			return fmt.Errorf("a SQLite database cannot be read from stdin")
+		}
+		err = readSQLite(filename, fn)
+	case FormatJSON:
+		err = readJSON(reader, fn)
+	case FormatJSONL:
+		err = readJSONL(reader, fn)
+	default:
+		err = readCSV(reader, opts.Delimiter, fn)
+	}
+	if err != nil && !errors.Is(err, ErrTruncated) {
+		return fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+	return err
+}
+
+// detect tells the format from the first bytes of the file
+func detect(r *bufio.Reader) (Format, error) {
+	if magic, _ := r.Peek(len(sqliteMagic)); string(magic) == sqliteMagic {
+		return FormatSQLite, nil
+	}
+	first, err := peekNonSpace(r)
+	if err == io.EOF {
+		return "", fmt.Errorf("file is empty")
+	}
+	if err != nil {
+		return "", err
+	}
+	switch first {
+	case '[':
+		return FormatJSON, nil
+	case '{':
+		return FormatJSONL, nil
+	}
+	return FormatCSV, nil
+}
+
+func hasFormat(formats []Format, f Format) bool {
+	for _, x := range formats {
+		if x == f {
+			return true
+		}
+	}
+	return false
+}
+
+func joinFormats(formats []Format) string {
+	names := make([]string, len(formats))
+	for i, f := range formats {
+		names[i] = string(f)
+	}
+	if len(names) == 1 {
+		return names[0]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1]
+}
+
+// peekNonSpace returns the first non-whitespace byte without consuming it
+func peekNonSpace(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		if b[0] != ' ' && b[0] != '\t' && b[0] != '\r' && b[0] != '\n' {
+			return b[0], nil
+		}
+		r.ReadByte()
+	}
+}
+
+// readJSON decodes an array element by element, so an array cut short by an
+// interrupted run still yields every complete result
+func readJSON(r io.Reader, fn func(*verifier.Result) error) error {
+	decoder := json.NewDecoder(r)
+	if _, err := decoder.Token(); err != nil {
+		return err
+	}
+	for decoder.More() {
+		var result verifier.Result
+		if err := decoder.Decode(&result); err != nil {
+			if isTruncatedJSON(err) {
+				return ErrTruncated
+			}
+			return err
+		}
+		if err := fn(&result); err != nil {
+			return err
+		}
+	}
+	if _, err := decoder.Token(); err != nil {
+		if isTruncatedJSON(err) {
+			return ErrTruncated
+		}
+		return err
+	}
+	return nil
+}
+
+func readJSONL(r io.Reader, fn func(*verifier.Result) error) error {
+	decoder := json.NewDecoder(r)
+	for decoder.More() {
+		var result verifier.Result
+		if err := decoder.Decode(&result); err != nil {
+			return err
+		}
+		if err := fn(&result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isTruncatedJSON reports whether a decode error means the input ended
+// before the JSON value did
+func isTruncatedJSON(err error) bool {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return strings.Contains(syntaxErr.Error(), "unexpected end of JSON input")
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// readSQLite reads the results table written by the SQLite output, which
+// keeps each full result as JSON
+func readSQLite(filename string, fn func(*verifier.Result) error) error {
+	db, err := sql.Open("sqlite", filename)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT result FROM results ORDER BY row, email")
+	if err != nil {
+		if strings.Contains(err.Error(), "no such table") {
+			return fmt.Errorf("not an emailchecker results database")
+		}
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return err
+		}
+		var result verifier.Result
+		if err := json.Unmarshal([]byte(data), &result); err != nil {
+			return err
+		}
+		if err := fn(&result); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// csvFields maps the JSON names of result fields, which CSV output uses as
+// headers, to their index in verifier.Result
+var csvFields = func() map[string]int {
+	fields := make(map[string]int)
+	t := reflect.TypeOf(verifier.Result{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" && name != "input" {
+			fields[name] = i
+		}
+	}
+	return fields
+}()
+
+// csvDelimiters are the delimiters detected in a CSV header
+var csvDelimiters = []rune{',', ';', '\t', '|'}
+
+// readCSV reads CSV output. Columns named after result fields fill them;
+// other columns are the input columns carried through. When a name occurs
+// twice, as with an input column called email, the later column is the
+// result field.
+func readCSV(r *bufio.Reader, delimiter rune, fn func(*verifier.Result) error) error {
+	if delimiter == 0 {
+		delimiter = sniffDelimiter(r)
+	}
+	reader := csv.NewReader(r)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return err
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+	last := make(map[string]int, len(header))
+	for i, h := range header {
+		header[i] = strings.TrimSpace(h)
+		last[header[i]] = i
+	}
+	for _, required := range []string{"email", "status"} {
+		if _, ok := last[required]; !ok {
+			return fmt.Errorf("no %s column in %v; CSV must be written with the %s field", required, header, required)
+		}
+	}
+
+	for {
+		values, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line, _ := reader.FieldPos(0)
+
+		result := &verifier.Result{}
+		v := reflect.ValueOf(result).Elem()
+		for i, value := range values {
+			if i >= len(header) {
+				break
+			}
+			name := header[i]
+			index, ok := csvFields[name]
+			if !ok || last[name] != i {
+				if result.Input == nil {
+					result.Input = make(map[string]string)
+				}
+				result.Input[name] = value
+				continue
+			}
+			if value == "" {
+				continue
+			}
+			if err := setField(v.Field(index), value); err != nil {
+				return fmt.Errorf("line %d, %s: %w", line, name, err)
+			}
+		}
+		if result.Domain == "" {
+			// Default CSV output has no domain column
+			if at := strings.LastIndex(result.Email, "@"); at >= 0 {
+				result.Domain = strings.ToLower(result.Email[at+1:])
+			}
+		}
+		if err := fn(result); err != nil {
+			return err
+		}
+	}
+}
+
+// sniffDelimiter picks the candidate that occurs most often in the header
+// line, comma when none does
+func sniffDelimiter(r *bufio.Reader) rune {
+	peek, _ := r.Peek(r.Size())
+	if i := bytes.IndexByte(peek, '\n'); i >= 0 {
+		peek = peek[:i]
+	}
+	best, count := ',', 0
+	for _, d := range csvDelimiters {
+		if n := bytes.Count(peek, []byte(string(d))); n > count {
+			best, count = d, n
+		}
+	}
+	return best
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// setField parses a CSV cell into a result field
+func setField(f reflect.Value, value string) error {
+	if f.Type() == timeType {
+		// Times in a custom layout cannot be parsed back; they stay unset
+		if t, ok := parseTime(value); ok {
+			f.Set(reflect.ValueOf(t))
+		}
+		return nil
+	}
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		f.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		f.SetInt(n)
+	case reflect.Float64:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		f.SetFloat(n)
+	case reflect.Slice:
+		if f.Type().Elem().Kind() == reflect.String && !strings.HasPrefix(value, "[") {
+			// Lists of strings are joined with ";"
+			f.Set(reflect.ValueOf(strings.Split(value, ";")))
+			return nil
+		}
+		return json.Unmarshal([]byte(value), f.Addr().Interface())
+	default:
+		return json.Unmarshal([]byte(value), f.Addr().Interface())
+	}
+	return nil
+}
+
+// parseTime reads the timestamps of the built-in time formats
+func parseTime(value string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, true
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05", value, time.Local); err == nil {
+		return t, true
+	}
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		// Unix milliseconds from 2001 on; seconds before that would be
+		// centuries away
+		if n > 1e12 {
+			return time.UnixMilli(n), true
+		}
+		return time.Unix(n, 0), true
+	}
+	return time.Time{}, false
+}
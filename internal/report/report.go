@@ -0,0 +1,330 @@
+package report
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nephila016/emailchecker/internal/verifier"
+)
+
+// None labels results with no value for a breakdown, such as no MX host
+const None = "(none)"
+
+// MinRateResults is how many results a domain needs before it is ranked by
+// invalid rate, so one bad address does not top the list
+const MinRateResults = 5
+
+// Counts tallies results by status
+type Counts struct {
+	Total   int `json:"total"`
+	Valid   int `json:"valid"`
+	Invalid int `json:"invalid"`
+	Unknown int `json:"unknown"`
+	Risky   int `json:"risky"`
+	Errors  int `json:"errors"`
+}
+
+// Add counts one result with the given status
+func (c *Counts) Add(status verifier.Status) {
+	c.Total++
+	switch status {
+	case verifier.StatusValid:
+		c.Valid++
+	case verifier.StatusInvalid:
+		c.Invalid++
+	case verifier.StatusUnknown:
+		c.Unknown++
+	case verifier.StatusRisky:
+		c.Risky++
+	case verifier.StatusError:
+		c.Errors++
+	}
+}
+
+// Share is n as a fraction of the total
+func (c Counts) Share(n int) float64 {
+	if c.Total == 0 {
+		return 0
+	}
+	return float64(n) / float64(c.Total)
+}
+
+// Item is a value and how many results had it
+type Item struct {
+	Name  string  `json:"name"`
+	Count int     `json:"count"`
+	Share float64 `json:"share"`
+}
+
+// Group is the status counts of the results sharing a value, such as a
+// domain
+type Group struct {
+	Name string `json:"name"`
+	Counts
+	InvalidRate float64 `json:"invalid_rate"`
+}
+
+// Bucket is one range of a latency histogram
+type Bucket struct {
+	Label string  `json:"label"`
+	Count int     `json:"count"`
+	Share float64 `json:"share"`
+}
+
+// Latency describes how long verifications took, in milliseconds
+type Latency struct {
+	Min     int64    `json:"min_ms"`
+	Mean    int64    `json:"mean_ms"`
+	P50     int64    `json:"p50_ms"`
+	P90     int64    `json:"p90_ms"`
+	P99     int64    `json:"p99_ms"`
+	Max     int64    `json:"max_ms"`
+	Buckets []Bucket `json:"buckets"`
+}
+
+// latencyBuckets are the upper bounds of the histogram ranges; the last
+// range is open
+var latencyBuckets = []struct {
+	label string
+	below int64
+}{
+	{"< 100ms", 100},
+	{"100-250ms", 250},
+	{"250-500ms", 500},
+	{"0.5-1s", 1000},
+	{"1-2s", 2000},
+	{"2-5s", 5000},
+	{"5-10s", 10000},
+	{">= 10s", 0},
+}
+
+// Report is the summary of a set of results. Breakdowns are sorted by
+// count, largest first.
+type Report struct {
+	Counts
+	CatchAll     int `json:"catch_all"`
+	Disposable   int `json:"disposable"`
+	RoleAccount  int `json:"role_account"`
+	FreeProvider int `json:"free_provider"`
+
+	SubStatuses    []Item  `json:"sub_statuses"`
+	Reasons        []Item  `json:"reasons"`
+	Domains        []Group `json:"domains"`
+	InvalidDomains []Group `json:"invalid_domains"`
+	MXHosts        []Group `json:"mx_hosts"`
+	Providers      []Group `json:"providers"`
+	Latency        Latency `json:"latency"`
+
+	FirstVerified time.Time `json:"first_verified,omitempty"`
+	LastVerified  time.Time `json:"last_verified,omitempty"`
+}
+
+// Stats accumulates results for a report. It is safe for concurrent use.
+type Stats struct {
+	mu sync.Mutex
+
+	counts       Counts
+	catchAll     int
+	disposable   int
+	roleAccount  int
+	freeProvider int
+
+	subStatuses map[string]int
+	reasons     map[string]int
+	domains     map[string]*Counts
+	mxHosts     map[string]*Counts
+	providers   map[string]*Counts
+	latencies   []int64
+
+	first, last time.Time
+}
+
+// New returns empty stats
+func New() *Stats {
+	return &Stats{
+		subStatuses: make(map[string]int),
+		reasons:     make(map[string]int),
+		domains:     make(map[string]*Counts),
+		mxHosts:     make(map[string]*Counts),
+		providers:   make(map[string]*Counts),
+	}
+}
+
+// Add counts one result
+func (s *Stats) Add(r *verifier.Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.counts.Add(r.Status)
+	if r.CatchAll {
+		s.catchAll++
+	}
+	if r.Disposable {
+		s.disposable++
+	}
+	if r.RoleAccount {
+		s.roleAccount++
+	}
+	if r.FreeProvider {
+		s.freeProvider++
+	}
+
+	if r.SubStatus != "" {
+		s.subStatuses[r.SubStatus]++
+	}
+	if r.Reason != "" {
+		s.reasons[r.Reason]++
+	}
+	addGroup(s.domains, strings.ToLower(r.Domain), r.Status)
+	addGroup(s.mxHosts, strings.ToLower(strings.TrimSuffix(r.MXHost, ".")), r.Status)
+	addGroup(s.providers, r.MailboxProvider, r.Status)
+	s.latencies = append(s.latencies, r.LatencyMs)
+
+	if t := r.VerifiedAt; !t.IsZero() {
+		if s.first.IsZero() || t.Before(s.first) {
+			s.first = t
+		}
+		if t.After(s.last) {
+			s.last = t
+		}
+	}
+}
+
+func addGroup(groups map[string]*Counts, name string, status verifier.Status) {
+	if name == "" {
+		name = None
+	}
+	c, ok := groups[name]
+	if !ok {
+		c = &Counts{}
+		groups[name] = c
+	}
+	c.Add(status)
+}
+
+// Report builds the report, keeping the top entries of each breakdown
+// (all of them when top <= 0)
+func (s *Stats) Report(top int) *Report {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := &Report{
+		Counts:        s.counts,
+		CatchAll:      s.catchAll,
+		Disposable:    s.disposable,
+		RoleAccount:   s.roleAccount,
+		FreeProvider:  s.freeProvider,
+		SubStatuses:   items(s.subStatuses, s.counts.Total, top),
+		Reasons:       items(s.reasons, s.counts.Total, top),
+		Domains:       groups(s.domains, top, byTotal),
+		MXHosts:       groups(s.mxHosts, top, byTotal),
+		Providers:     groups(s.providers, top, byTotal),
+		Latency:       latency(s.latencies),
+		FirstVerified: s.first,
+		LastVerified:  s.last,
+	}
+
+	rated := make(map[string]*Counts)
+	for name, c := range s.domains {
+		if c.Total >= MinRateResults && c.Invalid > 0 {
+			rated[name] = c
+		}
+	}
+	r.InvalidDomains = groups(rated, top, byInvalidRate)
+	return r
+}
+
+func items(counts map[string]int, total, top int) []Item {
+	list := make([]Item, 0, len(counts))
+	for name, n := range counts {
+		item := Item{Name: name, Count: n}
+		if total > 0 {
+			item.Share = float64(n) / float64(total)
+		}
+		list = append(list, item)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].Name < list[j].Name
+	})
+	if top > 0 && len(list) > top {
+		list = list[:top]
+	}
+	return list
+}
+
+func byTotal(a, b Group) bool {
+	return a.Total > b.Total
+}
+
+func byInvalidRate(a, b Group) bool {
+	if a.InvalidRate != b.InvalidRate {
+		return a.InvalidRate > b.InvalidRate
+	}
+	return a.Total > b.Total
+}
+
+func groups(counts map[string]*Counts, top int, less func(a, b Group) bool) []Group {
+	list := make([]Group, 0, len(counts))
+	for name, c := range counts {
+		list = append(list, Group{Name: name, Counts: *c, InvalidRate: c.Share(c.Invalid)})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if less(list[i], list[j]) {
+			return true
+		}
+		if less(list[j], list[i]) {
+			return false
+		}
+		return list[i].Name < list[j].Name
+	})
+	if top > 0 && len(list) > top {
+		list = list[:top]
+	}
+	return list
+}
+
+// latency summarizes verification times with nearest-rank percentiles
+func latency(values []int64) Latency {
+	l := Latency{Buckets: make([]Bucket, len(latencyBuckets))}
+	for i, b := range latencyBuckets {
+		l.Buckets[i].Label = b.label
+	}
+	if len(values) == 0 {
+		return l
+	}
+
+	sorted := append([]int64(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	percentile := func(p int) int64 {
+		rank := (p*len(sorted) + 99) / 100
+		if rank < 1 {
+			rank = 1
+		}
+		return sorted[rank-1]
+	}
+
+	var sum int64
+	for _, v := range sorted {
+		sum += v
+		i := 0
+		for i < len(latencyBuckets)-1 && v >= latencyBuckets[i].below {
+			i++
+		}
+		l.Buckets[i].Count++
+	}
+	for i := range l.Buckets {
+		l.Buckets[i].Share = float64(l.Buckets[i].Count) / float64(len(sorted))
+	}
+	l.Min = sorted[0]
+	l.Max = sorted[len(sorted)-1]
+	l.Mean = sum / int64(len(sorted))
+	l.P50 = percentile(50)
+	l.P90 = percentile(90)
+	l.P99 = percentile(99)
+	return l
+}
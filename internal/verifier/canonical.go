@@ -0,0 +1,70 @@
+package verifier
+
+import (
+	"strings"
+
+	"github.com/nephila016/emailchecker/internal/classifier"
+)
+
+// domainAliases maps alternate domains onto the domain that owns the mailbox
+var domainAliases = map[string]string{
+	"googlemail.com": "gmail.com",
+}
+
+// CanonicalizeEmail returns the form of email that identifies the underlying
+// mailbox, so that addresses delivering to the same inbox compare equal.
+// It is equivalent to CanonicalizeEmailMX with no MX information.
+func CanonicalizeEmail(email string) string {
+	return CanonicalizeEmailMX(email, nil)
+}
+
+// CanonicalizeEmailMX canonicalizes email using provider-aware rules:
+//   - googlemail.com is folded into gmail.com
+//   - Gmail ignores dots in the local part
+//   - "+tag" sub-addresses are stripped for Gmail, Google Workspace,
+//     Outlook and Microsoft 365
+//   - Yahoo "-keyword" disposable addresses are stripped
+//
+// Other providers keep the local part as is: many servers treat "+" as an
+// ordinary character, so a+b@corp.com and a@corp.com can be two mailboxes.
+//
+// mxHosts lets custom domains hosted on Google Workspace or Microsoft 365 be
+// recognized. Addresses that fail syntax validation are only normalized, and
+// quoted local parts are left untouched.
+func CanonicalizeEmailMX(email string, mxHosts []string) string {
+	// Parse without logging: this runs for every input line during dedup
+	addr, serr := parseAddress(NormalizeEmail(email), SyntaxPractical)
+	if serr != nil {
+		return NormalizeEmail(email)
+	}
+	localPart, domain := addr.LocalPart, addr.Domain
+
+	if alias, ok := domainAliases[domain]; ok {
+		domain = alias
+	}
+
+	if strings.HasPrefix(localPart, `"`) {
+		return localPart + "@" + domain
+	}
+
+	switch classifier.DetectProvider(domain, mxHosts) {
+	case classifier.ProviderGmail:
+		localPart = stripSubaddress(localPart, "+")
+		localPart = strings.ReplaceAll(localPart, ".", "")
+	case classifier.ProviderGoogleWorkspace, classifier.ProviderOutlook, classifier.ProviderMicrosoft365:
+		localPart = stripSubaddress(localPart, "+")
+	case classifier.ProviderYahoo:
+		localPart = stripSubaddress(localPart, "-")
+	}
+
+	return localPart + "@" + domain
+}
+
+// stripSubaddress removes everything from the first sep onwards, unless that
+// would leave the local part empty (e.g. "+tag@example.com")
+func stripSubaddress(localPart, sep string) string {
+	if i := strings.Index(localPart, sep); i > 0 {
+		return localPart[:i]
+	}
+	return localPart
+}
@@ -0,0 +1,410 @@
+package verifier
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nephila016/emailchecker/internal/classifier"
+	"github.com/nephila016/emailchecker/internal/debug"
+)
+
+// DefaultCatchAllProbes is how many random recipients are probed per domain
+const DefaultCatchAllProbes = 3
+
+// CatchAllThreshold is the probability at or above which a domain is
+// reported as catch-all
+const CatchAllThreshold = 0.7
+
+// acceptThenBounceHints are phrases servers use when they accept a recipient
+// without checking it, to reject or bounce it later
+var acceptThenBounceHints = []string{
+	"verified later",
+	"verify later",
+	"not verified",
+	"unverified",
+	"will be checked",
+	"cannot verify",
+	"accepted for forwarding",
+	"queued for verification",
+}
+
+// CatchAllVerdict is the outcome of probing a domain with random recipients
+type CatchAllVerdict struct {
+	// Probability that the server accepts any recipient (0-1)
+	Probability float64 `json:"probability"`
+	// AcceptThenBounce is set when the server accepts recipients at RCPT
+	// time but rejects them later (at DATA or by bounce)
+	AcceptThenBounce bool   `json:"accept_then_bounce"`
+	Probes           int    `json:"probes"`
+	Accepted         int    `json:"accepted"`
+	Reason           string `json:"reason"`
+}
+
+// IsCatchAll returns true if the probability reaches CatchAllThreshold
+func (c *CatchAllVerdict) IsCatchAll() bool {
+	return c.Probability >= CatchAllThreshold
+}
+
+// conclusive returns true if the verdict is worth reusing for other
+// addresses at the same domain (deferred probes are retried instead)
+func (c *CatchAllVerdict) conclusive() bool {
+	return c.Probes > 0 && !(c.Accepted == 0 && c.Probability > 0)
+}
+
+// probeNames feed the name-like probe shape
+var probeNames = []string{"james", "maria", "robert", "linda", "michael", "sarah", "david", "laura"}
+
+// CatchAllProbeAddresses returns n random recipients at domain in varied
+// shapes (random string, name-like, short, long), since some servers only
+// accept addresses that look plausible
+func CatchAllProbeAddresses(domain string, n int) []string {
+	if n <= 0 {
+		n = DefaultCatchAllProbes
+	}
+	shapes := []func() string{
+		func() string { return strings.TrimSuffix(GenerateRandomEmail(domain), "@"+domain) },
+		func() string {
+			return fmt.Sprintf("%s.%s%d", probeNames[randInt(len(probeNames))], randomString("abcdefghijklmnopqrstuvwxyz", 7), 10+randInt(90))
+		},
+		func() string { return randomString("abcdefghijklmnopqrstuvwxyz", 8) },
+		func() string { return randomString("abcdefghijklmnopqrstuvwxyz0123456789", 32) },
+	}
+
+	probes := make([]string, n)
+	for i := range probes {
+		probes[i] = shapes[i%len(shapes)]() + "@" + domain
+	}
+	return probes
+}
+
+// judgeCatchAll compares the probe replies with the reply for the real
+// address
+func judgeCatchAll(real RcptReply, probes []RcptReply) *CatchAllVerdict {
+	v := &CatchAllVerdict{Probes: len(probes)}
+	deferred := 0
+	for _, p := range probes {
+		switch {
+		case p.Code == 250 || p.Code == 251:
+			v.Accepted++
+			if hasAcceptThenBounceHint(p.Response) {
+				v.AcceptThenBounce = true
+			}
+		case p.Code >= 400 && p.Code < 500:
+			deferred++
+		}
+	}
+
+	switch {
+	case len(probes) == 0:
+		v.Reason = "no probes sent"
+	case v.Accepted == 0 && deferred == len(probes):
+		v.Probability = 0.4
+		v.Reason = "all probes were deferred"
+	case v.Accepted == 0:
+		v.Reason = "all probes were rejected"
+	case v.Accepted == len(probes):
+		v.Probability = 0.95
+		v.Reason = fmt.Sprintf("all %d probes accepted", len(probes))
+		if sameReplies(real, probes) {
+			v.Probability = 0.99
+			v.Reason += " with the same reply as the real address"
+		} else if real.Code == 250 || real.Code == 251 {
+			// The server words its answer differently for real mailboxes
+			v.Probability = 0.85
+			v.Reason += ", but the real address got a different reply"
+		}
+	default:
+		v.Probability = 0.3 + 0.5*float64(v.Accepted)/float64(len(probes))
+		v.Reason = fmt.Sprintf("%d of %d probes accepted (inconsistent)", v.Accepted, len(probes))
+	}
+
+	if v.AcceptThenBounce {
+		if v.Probability < 0.9 {
+			v.Probability = 0.9
+		}
+		v.Reason += "; server defers recipient checks"
+	}
+	return v
+}
+
+// replyAddress strips addresses from reply texts before comparing them
+var replyAddress = regexp.MustCompile(`<[^>]*>|\S+@\S+`)
+
+func normalizeReply(r RcptReply) string {
+	return fmt.Sprintf("%d %s", r.Code, strings.ToLower(strings.TrimSpace(replyAddress.ReplaceAllString(r.Response, ""))))
+}
+
+// sameReplies returns true if every probe got exactly the reply the real
+// address got (ignoring the addresses echoed in them)
+func sameReplies(real RcptReply, probes []RcptReply) bool {
+	want := normalizeReply(real)
+	for _, p := range probes {
+		if normalizeReply(p) != want {
+			return false
+		}
+	}
+	return true
+}
+
+func hasAcceptThenBounceHint(response string) bool {
+	r := strings.ToLower(response)
+	for _, hint := range acceptThenBounceHints {
+		if strings.Contains(r, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptedProbe returns the first probe whose RCPT was accepted
+func acceptedProbe(probes []string, replies []RcptReply) string {
+	for i, r := range replies {
+		if r.Code == 250 || r.Code == 251 {
+			return probes[i]
+		}
+	}
+	return ""
+}
+
+// probeDataStage checks whether a server that accepted a random recipient
+// rejects it once DATA is issued. The transaction contains only the probe;
+// if the server answers 354 the connection is dropped without sending a
+// message, so nothing is ever delivered.
+func probeDataStage(smtp *SMTPConnection, from, probe string) (bool, error) {
+	if err := smtp.Reset(); err != nil {
+		return false, err
+	}
+	replies, err := smtp.MailRcpt(from, []string{probe})
+	if err != nil {
+		return false, err
+	}
+	if replies[0].Code != 250 && replies[0].Code != 251 {
+		debug.GetLogger().Detail("CATCHALL", "DATA probe skipped: %s refused on retry (%d)", probe, replies[0].Code)
+		return false, nil
+	}
+
+	resp, err := smtp.sendCommand("DATA")
+	if err != nil {
+		return false, err
+	}
+	code := smtp.parseCode(resp)
+	if code == 354 {
+		smtp.Close()
+		return false, nil
+	}
+	return code >= 500, nil
+}
+
+// applyCatchAll records verdict on r and downgrades an accepted address to
+// risky when the domain accepts everything
+func (r *Result) applyCatchAll(verdict *CatchAllVerdict) {
+	r.CatchAllChecked = true
+	r.CatchAllProbability = verdict.Probability
+	r.AcceptThenBounce = verdict.AcceptThenBounce
+	if r.Status != StatusValid {
+		return
+	}
+	switch {
+	case verdict.AcceptThenBounce:
+		r.CatchAll = true
+		r.SetRisky("Server accepts recipients without checking them (accept-then-bounce)")
+		r.SubStatus = SubStatusAcceptThenBounce
+	case verdict.IsCatchAll():
+		r.CatchAll = true
+		r.SetRisky(fmt.Sprintf("Domain accepts all emails (catch-all, %.0f%% probability)", verdict.Probability*100))
+	}
+}
+
+// DefaultCatchAllCacheTTL is how long catch-all verdicts are reused
+const DefaultCatchAllCacheTTL = time.Hour
+
+// catchAllEntry is a cached verdict
+type catchAllEntry struct {
+	verdict *CatchAllVerdict
+	expires time.Time
+}
+
+// catchAllCache remembers verdicts per domain and per MX host so that a
+// bulk run probes each domain once instead of once per address
+type catchAllCache struct {
+	mu       sync.Mutex
+	entries  map[string]catchAllEntry
+	inflight map[string]chan struct{} // keys being probed, closed when done
+	hits     int
+	probes   int
+}
+
+// CatchAllCacheStats reports how often cached verdicts were reused
+type CatchAllCacheStats struct {
+	Probes int `json:"probes"` // domains actually probed
+	Hits   int `json:"hits"`   // addresses that reused a cached verdict
+}
+
+// catchAllKeys returns the cache keys for domain. Only self-hosted domains
+// are also keyed by MX host: shared providers host many unrelated domains
+// on the same MX, each with its own catch-all setting.
+func catchAllKeys(domain, mxHost, provider string) []string {
+	keys := []string{"domain:" + domain}
+	if mxHost != "" && provider == string(classifier.ProviderSelfHosted) {
+		keys = append(keys, "mx:"+strings.ToLower(mxHost))
+	}
+	return keys
+}
+
+// lookupLocked returns the first live verdict under keys
+func (c *catchAllCache) lookupLocked(keys []string) (*CatchAllVerdict, bool) {
+	now := time.Now()
+	for _, key := range keys {
+		entry, ok := c.entries[key]
+		if !ok {
+			continue
+		}
+		if now.After(entry.expires) {
+			delete(c.entries, key)
+			continue
+		}
+		c.hits++
+		return entry.verdict, true
+	}
+	return nil, false
+}
+
+// lookup returns a live verdict for domain (or for mxHost when the domain
+// is self-hosted) without waiting for a probe in flight
+func (c *catchAllCache) lookup(domain, mxHost, provider string) (*CatchAllVerdict, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lookupLocked(catchAllKeys(domain, mxHost, provider))
+}
+
+// acquire returns a live verdict for domain (or for mxHost when the domain
+// is self-hosted), or makes the caller the one probing it. While a probe is
+// in flight, other callers for the same key wait for its verdict instead of
+// running their own. A caller given a non-nil release must call it once the
+// verdict is stored or the probe failed.
+func (c *catchAllCache) acquire(domain, mxHost, provider string) (verdict *CatchAllVerdict, release func()) {
+	keys := catchAllKeys(domain, mxHost, provider)
+	key := keys[len(keys)-1]
+	for {
+		c.mu.Lock()
+		if verdict, ok := c.lookupLocked(keys); ok {
+			c.mu.Unlock()
+			return verdict, nil
+		}
+		wait, busy := c.inflight[key]
+		if !busy {
+			if c.inflight == nil {
+				c.inflight = make(map[string]chan struct{})
+			}
+			done := make(chan struct{})
+			c.inflight[key] = done
+			c.mu.Unlock()
+			return nil, func() {
+				c.mu.Lock()
+				delete(c.inflight, key)
+				c.mu.Unlock()
+				close(done)
+			}
+		}
+		c.mu.Unlock()
+		<-wait
+	}
+}
+
+// store records verdict for domain and, when self-hosted, for mxHost
+func (c *catchAllCache) store(domain, mxHost, provider string, verdict *CatchAllVerdict, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.probes++
+	if ttl < 0 {
+		return
+	}
+	if ttl == 0 {
+		ttl = DefaultCatchAllCacheTTL
+	}
+	if c.entries == nil {
+		c.entries = make(map[string]catchAllEntry)
+	}
+	entry := catchAllEntry{verdict: verdict, expires: time.Now().Add(ttl)}
+	for _, key := range catchAllKeys(domain, mxHost, provider) {
+		c.entries[key] = entry
+	}
+	debug.GetLogger().Detail("CATCHALL", "Cached verdict for %s via %s for %v: %.2f (%s)", domain, mxHost, ttl, verdict.Probability, verdict.Reason)
+}
+
+func (c *catchAllCache) stats() CatchAllCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CatchAllCacheStats{Probes: c.probes, Hits: c.hits}
+}
+
+// ProbeCatchAll checks whether the server in config accepts arbitrary
+// recipients at domain, without testing any real address
+func ProbeCatchAll(config *SMTPConfig, domain string) (*CatchAllVerdict, error) {
+	return probeCatchAll(config, domain, RcptReply{})
+}
+
+// probeCatchAll probes domain and judges the replies against real, the
+// reply an address at domain got (zero when there is none)
+func probeCatchAll(config *SMTPConfig, domain string, real RcptReply) (*CatchAllVerdict, error) {
+	log := debug.GetLogger()
+	log.Info("CATCHALL", "Testing catch-all for %s via %s", domain, config.Host)
+
+	smtp, err := dialSMTP(config)
+	if err != nil {
+		return nil, err
+	}
+	defer smtp.Close()
+
+	probes := CatchAllProbeAddresses(domain, config.CatchAllProbes)
+	replies, err := smtp.MailRcpt(config.FromAddress, probes)
+	if err != nil {
+		return nil, err
+	}
+	for i, r := range replies {
+		log.Detail("CATCHALL", "Probe %s: %d %s", probes[i], r.Code, r.Response)
+	}
+
+	verdict := judgeCatchAll(real, replies)
+	if config.CatchAllDataProbe && verdict.Accepted > 0 && !verdict.AcceptThenBounce {
+		bounced, err := probeDataStage(smtp, config.FromAddress, acceptedProbe(probes, replies))
+		if err != nil {
+			log.Detail("CATCHALL", "DATA probe failed: %v", err)
+		} else if bounced {
+			verdict.markBounced()
+		}
+	}
+
+	log.Info("CATCHALL", "Catch-all probability for %s: %.0f%% (%s)", domain, verdict.Probability*100, verdict.Reason)
+	return verdict, nil
+}
+
+// markBounced records that an accepted probe was rejected at DATA
+func (c *CatchAllVerdict) markBounced() {
+	c.AcceptThenBounce = true
+	c.Probability = math.Max(c.Probability, 0.9)
+	c.Reason += "; probe rejected at DATA"
+}
+
+func randInt(n int) int {
+	v, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0
+	}
+	return int(v.Int64())
+}
+
+func randomString(charset string, length int) string {
+	b := make([]byte, length)
+	for i := range b {
+		b[i] = charset[randInt(len(charset))]
+	}
+	return string(b)
+}
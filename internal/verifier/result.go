@@ -8,42 +8,86 @@ import (
 type Status string
 
 const (
-	StatusValid    Status = "valid"
-	StatusInvalid  Status = "invalid"
-	StatusUnknown  Status = "unknown"
-	StatusRisky    Status = "risky"
-	StatusError    Status = "error"
+	StatusValid   Status = "valid"
+	StatusInvalid Status = "invalid"
+	StatusUnknown Status = "unknown"
+	StatusRisky   Status = "risky"
+	StatusError   Status = "error"
 )
 
+// SubStatusUnverifiableUTF8 marks an internationalized address that could not
+// be probed because the mail server does not advertise SMTPUTF8.
+const SubStatusUnverifiableUTF8 = "unverifiable_utf8"
+
+// SubStatusAcceptThenBounce marks an address on a server that accepts every
+// recipient at RCPT time and rejects unknown ones later
+const SubStatusAcceptThenBounce = "accept_then_bounce"
+
+// SubStatusMalformedInput marks a bulk input row that could not be parsed,
+// so no address was verified for it
+const SubStatusMalformedInput = "malformed_input"
+
+// SubStatusProviderRule marks an address whose local part violates the
+// account-name rules of its mailbox provider
+const SubStatusProviderRule = "provider_rule"
+
 // Result contains the complete verification result
 type Result struct {
-	Email           string    `json:"email"`
-	Valid           bool      `json:"valid"`
-	Status          Status    `json:"status"`
-	StatusCode      int       `json:"status_code"`
-	Reason          string    `json:"reason"`
-	Disposable      bool      `json:"disposable"`
-	RoleAccount     bool      `json:"role_account"`
-	FreeProvider    bool      `json:"free_provider"`
-	CatchAll        bool      `json:"catch_all"`
-	CatchAllChecked bool      `json:"catch_all_checked"`
-	MXRecords       []string  `json:"mx_records"`
-	MXHost          string    `json:"mx_host"`
-	SMTPResponse    string    `json:"smtp_response"`
-	ConfidenceScore int       `json:"confidence_score"`
-	VerifiedAt      time.Time `json:"verified_at"`
-	LatencyMs       int64     `json:"latency_ms"`
+	Email               string              `json:"email"`
+	CanonicalEmail      string              `json:"canonical_email,omitempty"`
+	DidYouMean          string              `json:"did_you_mean,omitempty"`
+	Valid               bool                `json:"valid"`
+	Status              Status              `json:"status"`
+	SubStatus           string              `json:"sub_status,omitempty"`
+	StatusCode          int                 `json:"status_code"`
+	Reason              string              `json:"reason"`
+	Disposable          bool                `json:"disposable"`
+	DisposableReason    string              `json:"disposable_reason,omitempty"`
+	DisposableVia       string              `json:"disposable_via,omitempty"`
+	RoleAccount         bool                `json:"role_account"`
+	RoleCategory        string              `json:"role_category,omitempty"`
+	RoleConfidence      string              `json:"role_confidence,omitempty"`
+	LocalPartScore      int                 `json:"local_part_score"`
+	Generated           bool                `json:"generated"`
+	FreeProvider        bool                `json:"free_provider"`
+	MailboxProvider     string              `json:"mailbox_provider,omitempty"`
+	Tags                []string            `json:"tags,omitempty"`
+	CatchAll            bool                `json:"catch_all"`
+	CatchAllChecked     bool                `json:"catch_all_checked"`
+	CatchAllProbability float64             `json:"catch_all_probability"`
+	AcceptThenBounce    bool                `json:"accept_then_bounce,omitempty"`
+	MXRecords           []string            `json:"mx_records"`
+	MXHost              string              `json:"mx_host"`
+	SMTPResponse        string              `json:"smtp_response"`
+	ConfidenceScore     int                 `json:"confidence_score"`
+	ScoreProfile        string              `json:"score_profile,omitempty"`
+	ScoreBreakdown      []ScoreContribution `json:"score_breakdown,omitempty"`
+	VerifiedAt          time.Time           `json:"verified_at"`
+	LatencyMs           int64               `json:"latency_ms"`
 
 	// Syntax check results
 	SyntaxValid bool   `json:"syntax_valid"`
+	SyntaxError string `json:"syntax_error,omitempty"`
 	LocalPart   string `json:"local_part"`
 	Domain      string `json:"domain"`
+	SMTPUTF8    bool   `json:"smtputf8"`
 
 	// Additional info
 	HasMX       bool   `json:"has_mx"`
 	SMTPSuccess bool   `json:"smtp_success"`
 	TLSUsed     bool   `json:"tls_used"`
 	Error       string `json:"error,omitempty"`
+
+	// Bulk input: the source row and its original columns
+	Row   int               `json:"row,omitempty"`
+	Input map[string]string `json:"input,omitempty"`
+
+	// Index is the position of the job that produced this result in a
+	// worker pool run
+	Index int `json:"-"`
+
+	// catchAllVerdict is the verdict the SMTP layer produced, for caching
+	catchAllVerdict *CatchAllVerdict
 }
 
 // NewResult creates a new Result with default values
@@ -92,6 +136,13 @@ func (r *Result) SetRisky(reason string) {
 	r.ConfidenceScore = calculateConfidence(r)
 }
 
+// SetUnverifiableUTF8 marks an internationalized address as unknown because
+// the server cannot accept it without the SMTPUTF8 extension
+func (r *Result) SetUnverifiableUTF8() {
+	r.SetUnknown("Server does not support SMTPUTF8; internationalized address cannot be verified")
+	r.SubStatus = SubStatusUnverifiableUTF8
+}
+
 // SetError marks the result as error
 func (r *Result) SetError(err error) {
 	r.Valid = false
@@ -101,54 +152,10 @@ func (r *Result) SetError(err error) {
 	r.ConfidenceScore = 0
 }
 
-// calculateConfidence calculates a confidence score 0-100
+// calculateConfidence calculates a confidence score 0-100 with the default
+// scoring model. Verifier.Verify rescores with the configured profile.
 func calculateConfidence(r *Result) int {
-	score := 0
-
-	// Syntax valid adds base score
-	if r.SyntaxValid {
-		score += 10
-	}
-
-	// Has MX records
-	if r.HasMX {
-		score += 15
-	}
-
-	// SMTP verification results
-	switch r.Status {
-	case StatusValid:
-		score += 60
-		if r.StatusCode == 250 {
-			score += 15
-		}
-	case StatusInvalid:
-		score = 0 // Reset for invalid
-	case StatusRisky:
-		score += 30 // Catch-all or uncertain
-	case StatusUnknown:
-		score += 20
-	}
-
-	// Deductions
-	if r.Disposable {
-		score -= 20
-	}
-	if r.CatchAll {
-		score -= 25
-	}
-	if r.RoleAccount {
-		score -= 5
-	}
-
-	// Clamp to 0-100
-	if score < 0 {
-		score = 0
-	}
-	if score > 100 {
-		score = 100
-	}
-
+	score, _ := defaultScoringModel.Score(r)
 	return score
 }
 
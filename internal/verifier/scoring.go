@@ -0,0 +1,250 @@
+package verifier
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// scoreFactors are the conditions a scoring model can weight, in the order
+// they appear in score breakdowns
+var scoreFactors = []struct {
+	name string
+	test func(r *Result) bool
+}{
+	{"syntax_valid", func(r *Result) bool { return r.SyntaxValid }},
+	{"has_mx", func(r *Result) bool { return r.HasMX }},
+	{"status_valid", func(r *Result) bool { return r.Status == StatusValid }},
+	{"status_risky", func(r *Result) bool { return r.Status == StatusRisky }},
+	{"status_unknown", func(r *Result) bool { return r.Status == StatusUnknown }},
+	{"status_invalid", func(r *Result) bool { return r.Status == StatusInvalid }},
+	{"status_error", func(r *Result) bool { return r.Status == StatusError }},
+	{"status_code_250", func(r *Result) bool { return r.Status == StatusValid && r.StatusCode == 250 }},
+	{"tls_used", func(r *Result) bool { return r.TLSUsed }},
+	{"disposable", func(r *Result) bool { return r.Disposable }},
+	{"catch_all", func(r *Result) bool { return r.CatchAll }},
+	{"role_account", func(r *Result) bool { return r.RoleAccount }},
+	{"free_provider", func(r *Result) bool { return r.FreeProvider }},
+	{"generated", func(r *Result) bool { return r.Generated }},
+	{"did_you_mean", func(r *Result) bool { return r.DidYouMean != "" }},
+	{"tagged", func(r *Result) bool { return len(r.Tags) > 0 }},
+}
+
+// ScoreFactors returns the names of every factor a scoring model can use
+func ScoreFactors() []string {
+	names := make([]string, len(scoreFactors))
+	for i, f := range scoreFactors {
+		names[i] = f.name
+	}
+	return names
+}
+
+func scoreFactor(name string) (func(r *Result) bool, bool) {
+	for _, f := range scoreFactors {
+		if f.name == name {
+			return f.test, true
+		}
+	}
+	return nil, false
+}
+
+// ScoreRule caps the score when every condition in When holds. A condition
+// is a factor name, optionally prefixed with "!" to negate it.
+type ScoreRule struct {
+	Name string   `mapstructure:"name" json:"name"`
+	When []string `mapstructure:"when" json:"when"`
+	Cap  int      `mapstructure:"cap" json:"cap"`
+}
+
+// ScoringModel turns a Result into a confidence score: the weights of every
+// factor that holds are summed, rules cap the total, and the result is
+// clamped to 0-100.
+type ScoringModel struct {
+	Name    string         `mapstructure:"-" json:"name"`
+	Weights map[string]int `mapstructure:"weights" json:"weights"`
+	Rules   []ScoreRule    `mapstructure:"rules" json:"rules,omitempty"`
+}
+
+// ScoreContribution is one line of a score breakdown
+type ScoreContribution struct {
+	Factor string `json:"factor"`
+	Points int    `json:"points"`
+}
+
+// DefaultScoringModel returns the built-in "default" profile
+func DefaultScoringModel() *ScoringModel {
+	return &ScoringModel{
+		Name: "default",
+		Weights: map[string]int{
+			"syntax_valid":    10,
+			"has_mx":          15,
+			"status_valid":    60,
+			"status_code_250": 15,
+			"status_risky":    30,
+			"status_unknown":  20,
+			"disposable":      -20,
+			"catch_all":       -25,
+			"role_account":    -5,
+			"generated":       -15,
+		},
+		// Errors are not capped: they keep the syntax and MX points, as
+		// scores did before profiles existed
+		Rules: []ScoreRule{
+			{Name: "invalid", When: []string{"status_invalid"}, Cap: 0},
+		},
+	}
+}
+
+// defaultScoringModel backs calculateConfidence
+var defaultScoringModel = DefaultScoringModel()
+
+// builtinProfiles are profiles available without configuration. They are
+// overlays on the default profile.
+var builtinProfiles = map[string]*ScoringModel{
+	"strict": {
+		Weights: map[string]int{
+			"catch_all":     -40,
+			"role_account":  -15,
+			"free_provider": -5,
+			"generated":     -30,
+			"did_you_mean":  -20,
+		},
+		Rules: []ScoreRule{
+			{Name: "disposable", When: []string{"disposable"}, Cap: 10},
+			{Name: "unverified", When: []string{"!status_valid"}, Cap: 50},
+		},
+	},
+	"lenient": {
+		Weights: map[string]int{
+			"status_risky":   45,
+			"status_unknown": 35,
+			"catch_all":      -10,
+			"role_account":   0,
+			"generated":      -5,
+		},
+	},
+}
+
+// ScoringProfileNames returns the names of the built-in profiles
+func ScoringProfileNames() []string {
+	names := []string{"default"}
+	for name := range builtinProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// BuiltinScoringProfile returns a built-in profile by name
+func BuiltinScoringProfile(name string) (*ScoringModel, bool) {
+	if name == "" || name == "default" {
+		return DefaultScoringModel(), true
+	}
+	overlay, ok := builtinProfiles[name]
+	if !ok {
+		return nil, false
+	}
+	m := DefaultScoringModel().Merge(overlay)
+	m.Name = name
+	return m, true
+}
+
+// Merge returns a copy of m with the weights of over replacing m's and the
+// rules of over appended to m's
+func (m *ScoringModel) Merge(over *ScoringModel) *ScoringModel {
+	merged := &ScoringModel{
+		Name:    m.Name,
+		Weights: make(map[string]int, len(m.Weights)),
+		Rules:   append([]ScoreRule{}, m.Rules...),
+	}
+	for k, w := range m.Weights {
+		merged.Weights[k] = w
+	}
+	if over == nil {
+		return merged
+	}
+	if over.Name != "" {
+		merged.Name = over.Name
+	}
+	for k, w := range over.Weights {
+		merged.Weights[k] = w
+	}
+	merged.Rules = append(merged.Rules, over.Rules...)
+	return merged
+}
+
+// Validate checks that every weight and rule refers to a known factor
+func (m *ScoringModel) Validate() error {
+	for name := range m.Weights {
+		if _, ok := scoreFactor(name); !ok {
+			return fmt.Errorf("scoring profile %q: unknown factor %q (known: %s)", m.Name, name, strings.Join(ScoreFactors(), ", "))
+		}
+	}
+	for _, rule := range m.Rules {
+		if len(rule.When) == 0 {
+			return fmt.Errorf("scoring profile %q: rule %q has no conditions", m.Name, rule.Name)
+		}
+		for _, cond := range rule.When {
+			if _, ok := scoreFactor(strings.TrimPrefix(cond, "!")); !ok {
+				return fmt.Errorf("scoring profile %q: rule %q: unknown factor %q", m.Name, rule.Name, cond)
+			}
+		}
+	}
+	return nil
+}
+
+// Score computes the confidence score of r and the contributions that make
+// it up. Unknown factors are ignored; call Validate to reject them.
+func (m *ScoringModel) Score(r *Result) (int, []ScoreContribution) {
+	var breakdown []ScoreContribution
+	score := 0
+
+	for _, f := range scoreFactors {
+		w := m.Weights[f.name]
+		if w != 0 && f.test(r) {
+			score += w
+			breakdown = append(breakdown, ScoreContribution{Factor: f.name, Points: w})
+		}
+	}
+
+	for _, rule := range m.Rules {
+		if score > rule.Cap && rule.matches(r) {
+			breakdown = append(breakdown, ScoreContribution{Factor: "rule:" + rule.Name, Points: rule.Cap - score})
+			score = rule.Cap
+		}
+	}
+
+	// Clamp to 0-100
+	if score < 0 {
+		breakdown = append(breakdown, ScoreContribution{Factor: "clamp", Points: -score})
+		score = 0
+	}
+	if score > 100 {
+		breakdown = append(breakdown, ScoreContribution{Factor: "clamp", Points: 100 - score})
+		score = 100
+	}
+
+	return score, breakdown
+}
+
+// matches returns true if every condition of the rule holds for r
+func (rule ScoreRule) matches(r *Result) bool {
+	for _, cond := range rule.When {
+		want := !strings.HasPrefix(cond, "!")
+		test, ok := scoreFactor(strings.TrimPrefix(cond, "!"))
+		if !ok || test(r) != want {
+			return false
+		}
+	}
+	return true
+}
+
+// ApplyScore scores r with m and records the profile and breakdown on it.
+// A nil model uses the default profile.
+func (r *Result) ApplyScore(m *ScoringModel) {
+	if m == nil {
+		m = DefaultScoringModel()
+	}
+	r.ConfidenceScore, r.ScoreBreakdown = m.Score(r)
+	r.ScoreProfile = m.Name
+}
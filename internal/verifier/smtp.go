@@ -4,7 +4,9 @@ import (
 	"bufio"
 	"crypto/rand"
 	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"strconv"
 	"strings"
@@ -26,6 +28,12 @@ type SMTPConfig struct {
 	HELODomain    string
 	ForceTLS      bool
 	SkipTLSVerify bool
+	// CatchAllProbes is how many random recipients to probe when checking
+	// for catch-all (0 = DefaultCatchAllProbes)
+	CatchAllProbes int
+	// CatchAllDataProbe issues DATA for an accepted random recipient to
+	// detect servers that reject unknown recipients only after RCPT
+	CatchAllDataProbe bool
 }
 
 // DefaultSMTPConfig returns default SMTP configuration
@@ -47,6 +55,7 @@ type SMTPConnection struct {
 	useTLS   bool
 	banner   string
 	features map[string]bool
+	smtputf8 bool
 }
 
 // NewSMTPConnection creates a new SMTP connection
@@ -60,7 +69,7 @@ func NewSMTPConnection(config *SMTPConfig) *SMTPConnection {
 // Connect establishes connection to SMTP server
 func (s *SMTPConnection) Connect() error {
 	log := debug.GetLogger()
-	addr := fmt.Sprintf("%s:%d", s.config.Host, s.config.Port)
+	addr := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
 
 	timer := log.StartTimer("SMTP", fmt.Sprintf("Connecting to %s", addr))
 
@@ -191,7 +200,7 @@ func (s *SMTPConnection) StartTLS() error {
 func (s *SMTPConnection) MailFrom(from string) error {
 	log := debug.GetLogger()
 
-	response, err := s.sendCommand(fmt.Sprintf("MAIL FROM:<%s>", from))
+	response, err := s.sendCommand(s.mailFromCommand(from))
 	if err != nil {
 		return err
 	}
@@ -205,7 +214,7 @@ func (s *SMTPConnection) MailFrom(from string) error {
 			return err
 		}
 		// Retry MAIL FROM after TLS upgrade
-		response, err = s.sendCommand(fmt.Sprintf("MAIL FROM:<%s>", from))
+		response, err = s.sendCommand(s.mailFromCommand(from))
 		if err != nil {
 			return err
 		}
@@ -219,6 +228,99 @@ func (s *SMTPConnection) MailFrom(from string) error {
 	return nil
 }
 
+// mailFromCommand builds the MAIL FROM command, adding the SMTPUTF8
+// parameter when UseSMTPUTF8 has been enabled for this connection.
+func (s *SMTPConnection) mailFromCommand(from string) string {
+	if s.smtputf8 {
+		return fmt.Sprintf("MAIL FROM:<%s> SMTPUTF8", from)
+	}
+	return fmt.Sprintf("MAIL FROM:<%s>", from)
+}
+
+// RcptReply holds the server reply to a single RCPT TO command
+type RcptReply struct {
+	Code     int
+	Response string
+}
+
+// MailRcpt sends MAIL FROM followed by one RCPT TO per recipient and returns
+// the RCPT replies in recipient order. When the server advertises PIPELINING
+// (RFC 2920) the whole transaction is sent as a single command group;
+// otherwise the commands are issued lock-step. If the conversation fails
+// part-way, the replies received so far are returned with the error.
+func (s *SMTPConnection) MailRcpt(from string, recipients []string) ([]RcptReply, error) {
+	if s.SupportsPipelining() {
+		replies, err := s.pipelineMailRcpt(from, recipients)
+		if err != errSTARTTLSRequired {
+			return replies, err
+		}
+		// The server wants TLS first; upgrade and retry (EHLO is re-sent, so
+		// pipelining support is re-evaluated against the new feature list).
+		log := debug.GetLogger()
+		log.Detail("SMTP", "Server requires STARTTLS")
+		if err := s.StartTLS(); err != nil {
+			return nil, err
+		}
+		if s.SupportsPipelining() {
+			replies, err = s.pipelineMailRcpt(from, recipients)
+			if err == errSTARTTLSRequired {
+				return nil, fmt.Errorf("MAIL FROM rejected: STARTTLS required after TLS upgrade")
+			}
+			return replies, err
+		}
+	}
+
+	if err := s.MailFrom(from); err != nil {
+		return nil, err
+	}
+
+	replies := make([]RcptReply, 0, len(recipients))
+	for _, rcpt := range recipients {
+		code, response, err := s.RcptTo(rcpt)
+		if err != nil {
+			return replies, err
+		}
+		replies = append(replies, RcptReply{Code: code, Response: response})
+	}
+	return replies, nil
+}
+
+// errSTARTTLSRequired is returned by pipelineMailRcpt when the server rejects
+// MAIL FROM with 530 asking for STARTTLS.
+var errSTARTTLSRequired = errors.New("STARTTLS required")
+
+// pipelineMailRcpt sends MAIL FROM and all RCPT TO commands in one write and
+// parses the ordered responses.
+func (s *SMTPConnection) pipelineMailRcpt(from string, recipients []string) ([]RcptReply, error) {
+	cmds := make([]string, 0, len(recipients)+1)
+	cmds = append(cmds, s.mailFromCommand(from))
+	for _, rcpt := range recipients {
+		cmds = append(cmds, fmt.Sprintf("RCPT TO:<%s>", rcpt))
+	}
+
+	responses, err := s.sendPipelined(cmds)
+	if len(responses) == 0 {
+		return nil, err
+	}
+
+	code := s.parseCode(responses[0])
+	if code == 530 && strings.Contains(strings.ToUpper(responses[0]), "STARTTLS") {
+		return nil, errSTARTTLSRequired
+	}
+	if code != 250 {
+		return nil, fmt.Errorf("MAIL FROM rejected with code %d: %s", code, responses[0])
+	}
+
+	replies := make([]RcptReply, 0, len(recipients))
+	for _, response := range responses[1:] {
+		replies = append(replies, RcptReply{
+			Code:     s.parseCode(response),
+			Response: strings.TrimSpace(response),
+		})
+	}
+	return replies, err
+}
+
 // RcptTo sends RCPT TO command and returns the result
 func (s *SMTPConnection) RcptTo(email string) (int, string, error) {
 	response, err := s.sendCommand(fmt.Sprintf("RCPT TO:<%s>", email))
@@ -261,6 +363,26 @@ func (s *SMTPConnection) SupportsTLS() bool {
 	return s.features["STARTTLS"]
 }
 
+// SupportsPipelining returns true if server advertised PIPELINING (RFC 2920)
+func (s *SMTPConnection) SupportsPipelining() bool {
+	return s.features["PIPELINING"]
+}
+
+// SupportsSMTPUTF8 returns true if server advertised SMTPUTF8 (RFC 6531)
+func (s *SMTPConnection) SupportsSMTPUTF8() bool {
+	return s.features["SMTPUTF8"]
+}
+
+// UseSMTPUTF8 makes subsequent MAIL FROM commands request SMTPUTF8.
+// It fails if the server did not advertise the extension.
+func (s *SMTPConnection) UseSMTPUTF8() error {
+	if !s.SupportsSMTPUTF8() {
+		return errors.New("server does not support SMTPUTF8")
+	}
+	s.smtputf8 = true
+	return nil
+}
+
 // UsingTLS returns true if connection is using TLS
 func (s *SMTPConnection) UsingTLS() bool {
 	return s.useTLS
@@ -289,6 +411,39 @@ func (s *SMTPConnection) sendCommand(cmd string) (string, error) {
 	return response, nil
 }
 
+// sendPipelined writes a group of commands in a single write and reads one
+// response per command, in order. If reading fails part-way, the responses
+// read so far are returned with the error. Only valid when the server
+// supports PIPELINING.
+func (s *SMTPConnection) sendPipelined(cmds []string) ([]string, error) {
+	log := debug.GetLogger()
+
+	var batch strings.Builder
+	for _, cmd := range cmds {
+		log.SMTPSend(cmd)
+		batch.WriteString(cmd)
+		batch.WriteString("\r\n")
+	}
+
+	s.conn.SetDeadline(time.Now().Add(s.config.Timeout))
+
+	if _, err := io.WriteString(s.conn, batch.String()); err != nil {
+		return nil, fmt.Errorf("failed to send pipelined commands: %w", err)
+	}
+
+	responses := make([]string, 0, len(cmds))
+	for range cmds {
+		response, err := s.readResponse()
+		if err != nil {
+			return responses, err
+		}
+		log.SMTPRecv(strings.TrimSpace(response))
+		responses = append(responses, response)
+	}
+
+	return responses, nil
+}
+
 // readResponse reads a (possibly multi-line) SMTP response.
 // It enforces maxSMTPResponseSize to protect against memory exhaustion.
 func (s *SMTPConnection) readResponse() (string, error) {
@@ -364,6 +519,27 @@ func GenerateRandomEmail(domain string) string {
 	return fmt.Sprintf("emailchecker_test_%s@%s", string(result), domain)
 }
 
+// dialSMTP connects, greets and upgrades to TLS when offered
+func dialSMTP(config *SMTPConfig) (*SMTPConnection, error) {
+	smtp := NewSMTPConnection(config)
+	if err := smtp.Connect(); err != nil {
+		smtp.Close()
+		return nil, err
+	}
+	if err := smtp.EHLO(); err != nil {
+		smtp.Close()
+		return nil, err
+	}
+
+	// Try STARTTLS if available
+	if smtp.SupportsTLS() {
+		if err := smtp.StartTLS(); err != nil {
+			debug.GetLogger().Detail("SMTP", "STARTTLS failed, continuing without TLS: %v", err)
+		}
+	}
+	return smtp, nil
+}
+
 // VerifyEmail performs SMTP verification for a single email
 func VerifyEmail(config *SMTPConfig, email string, checkCatchAll bool) (*Result, error) {
 	log := debug.GetLogger()
@@ -376,40 +552,47 @@ func VerifyEmail(config *SMTPConfig, email string, checkCatchAll bool) (*Result,
 	}()
 
 	// Create and connect
-	smtp := NewSMTPConnection(config)
-	defer smtp.Close()
-
-	if err := smtp.Connect(); err != nil {
-		result.SetError(err)
-		return result, err
-	}
-
-	if err := smtp.EHLO(); err != nil {
+	smtp, err := dialSMTP(config)
+	if err != nil {
 		result.SetError(err)
 		return result, err
 	}
+	defer smtp.Close()
+	result.TLSUsed = smtp.UsingTLS()
 
-	// Try STARTTLS if available
-	if smtp.SupportsTLS() {
-		if err := smtp.StartTLS(); err != nil {
-			log.Detail("SMTP", "STARTTLS failed, continuing without TLS: %v", err)
+	// Internationalized local parts can only be probed over SMTPUTF8
+	if !isASCII(email) {
+		result.SMTPUTF8 = true
+		if err := smtp.UseSMTPUTF8(); err != nil {
+			result.SetUnverifiableUTF8()
+			log.Info("VERIFY", "Email UNVERIFIABLE: %s (server lacks SMTPUTF8)", email)
+			return result, nil
 		}
 	}
-	result.TLSUsed = smtp.UsingTLS()
 
-	// MAIL FROM
-	if err := smtp.MailFrom(config.FromAddress); err != nil {
-		result.SetError(err)
-		return result, err
+	// MAIL FROM + RCPT TO — the actual mailbox probe. When catch-all
+	// detection is requested from a pipelining server, the random probes
+	// ride in the same command group and are answered in one round-trip.
+	// Other servers only see the probes once the real address is accepted.
+	pipelineProbes := checkCatchAll && smtp.SupportsPipelining()
+	recipients := []string{email}
+	if pipelineProbes {
+		recipients = append(recipients, CatchAllProbeAddresses(emailDomain(email), config.CatchAllProbes)...)
 	}
 
-	// RCPT TO — the actual mailbox probe
-	code, response, err := smtp.RcptTo(email)
-	if err != nil {
+	// A server may answer the real address and then drop the connection on
+	// a probe: the address keeps its reply, only the probes are void
+	replies, err := smtp.MailRcpt(config.FromAddress, recipients)
+	if len(replies) == 0 {
 		result.SetError(err)
 		return result, err
 	}
+	probesFailed := err != nil
+	if probesFailed {
+		log.Detail("CATCHALL", "Catch-all probes failed: %v", err)
+	}
 
+	code, response := replies[0].Code, replies[0].Response
 	result.StatusCode = code
 	result.SMTPResponse = response
 
@@ -436,30 +619,54 @@ func VerifyEmail(config *SMTPConfig, email string, checkCatchAll bool) (*Result,
 		log.Info("VERIFY", "Email UNKNOWN: %s (code: %d)", email, code)
 	}
 
-	// Catch-all detection: only when email was valid and caller requested it
-	if checkCatchAll && result.Status == StatusValid {
-		if err := smtp.Reset(); err == nil {
-			if err := smtp.MailFrom(config.FromAddress); err == nil {
-				randomEmail := GenerateRandomEmail(result.Domain)
-				log.Detail("CATCHALL", "Testing with random email: %s", randomEmail)
-
-				catchCode, _, _ := smtp.RcptTo(randomEmail)
-				result.CatchAllChecked = true
+	accepted := code == 250 || code == 251
+	if checkCatchAll && !pipelineProbes && accepted {
+		for _, probe := range CatchAllProbeAddresses(emailDomain(email), config.CatchAllProbes) {
+			probeCode, probeResponse, err := smtp.RcptTo(probe)
+			if err != nil {
+				log.Detail("CATCHALL", "Probe %s failed: %v", probe, err)
+				probesFailed = true
+				break
+			}
+			recipients = append(recipients, probe)
+			replies = append(replies, RcptReply{Code: probeCode, Response: probeResponse})
+		}
+	}
 
-				if catchCode == 250 || catchCode == 251 {
-					result.CatchAll = true
-					result.SetRisky("Domain accepts all emails (catch-all)")
-					log.Info("CATCHALL", "Domain is catch-all: %s", result.Domain)
-				} else {
-					log.Detail("CATCHALL", "Domain is NOT catch-all (random email rejected with %d)", catchCode)
-				}
+	// Catch-all detection: compare the random probes with the real address.
+	// Probes only say something about the domain when the real address was
+	// accepted, so a pipelined verdict is discarded otherwise.
+	if checkCatchAll && accepted && !probesFailed && len(replies) > 1 {
+		for i, r := range replies[1:] {
+			log.Detail("CATCHALL", "Probe %s: %d %s", recipients[i+1], r.Code, r.Response)
+		}
+		verdict := judgeCatchAll(replies[0], replies[1:])
+
+		if config.CatchAllDataProbe && verdict.Accepted > 0 && !verdict.AcceptThenBounce {
+			bounced, err := probeDataStage(smtp, config.FromAddress, acceptedProbe(recipients[1:], replies[1:]))
+			if err != nil {
+				log.Detail("CATCHALL", "DATA probe failed: %v", err)
+			} else if bounced {
+				verdict.markBounced()
 			}
 		}
+
+		log.Info("CATCHALL", "Catch-all probability for %s: %.0f%% (%s)", emailDomain(email), verdict.Probability*100, verdict.Reason)
+		result.catchAllVerdict = verdict
+		result.applyCatchAll(verdict)
 	}
 
 	return result, nil
 }
 
+// emailDomain returns the part of email after the last @
+func emailDomain(email string) string {
+	if i := strings.LastIndex(email, "@"); i >= 0 {
+		return email[i+1:]
+	}
+	return email
+}
+
 // parseRejectionReason extracts a human-readable reason from an SMTP rejection response
 func parseRejectionReason(response string) string {
 	r := strings.ToLower(response)
@@ -1,136 +1,375 @@
 package verifier
 
 import (
-	"regexp"
+	"net"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/nephila016/emailchecker/internal/debug"
+	"golang.org/x/net/idna"
+	"golang.org/x/text/unicode/norm"
 )
 
-// RFC 5322 compliant email regex (simplified but effective)
-var emailRegex = regexp.MustCompile(`^[a-zA-Z0-9.!#$%&'*+/=?^_` + "`" + `{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+// Address is a parsed RFC 5321 mailbox
+type Address struct {
+	// LocalPart is the local part as written (quotes included when quoted)
+	LocalPart string
+	// Domain is the ASCII domain: IDNs are converted to A-labels and address
+	// literals keep their brackets (e.g. "[192.0.2.1]")
+	Domain string
+	// Quoted is true when the local part is a quoted string
+	Quoted bool
+	// LiteralIP is set when the domain is an address literal
+	LiteralIP net.IP
+}
+
+// String returns the address in ASCII-domain form
+func (a *Address) String() string {
+	return a.LocalPart + "@" + a.Domain
+}
 
-// ValidateSyntax checks if the email has valid syntax
+// ValidateSyntax checks if the email has valid syntax at the practical
+// strictness level.
+// Internationalized domains are converted to their IDNA-2008 A-label form,
+// so the returned domain is always ASCII and safe to use for DNS and SMTP.
+// The local part may contain UTF-8 (RFC 6531) and is returned NFC-normalized.
 func ValidateSyntax(email string) (localPart, domain string, valid bool) {
+	addr, err := ParseAddress(email, SyntaxPractical)
+	if err != nil {
+		return "", "", false
+	}
+	return addr.LocalPart, addr.Domain, true
+}
+
+// ParseAddress parses email as an RFC 5321 Mailbox (Local-part "@" Domain)
+// at the given strictness level. The input is trimmed, lowercased and
+// NFC-normalized first. On failure the returned error is a *SyntaxError.
+func ParseAddress(email string, level SyntaxLevel) (*Address, error) {
 	log := debug.GetLogger()
 
 	email = strings.TrimSpace(email)
 	email = strings.ToLower(email)
 
-	log.Info("SYNTAX", "Validating syntax for: %s", email)
+	log.Info("SYNTAX", "Validating syntax for: %s (level: %s)", email, level)
+
+	addr, serr := parseAddress(email, level)
+	if serr != nil {
+		log.Detail("SYNTAX", "Invalid syntax: %s [%s]", serr.Error(), serr.Code)
+		return nil, serr
+	}
+
+	log.Success("SYNTAX", "Valid syntax - local: %s, domain: %s", addr.LocalPart, addr.Domain)
+	return addr, nil
+}
 
-	// Basic checks
+func parseAddress(email string, level SyntaxLevel) (*Address, *SyntaxError) {
 	if email == "" {
-		log.Detail("SYNTAX", "Empty email address")
-		return "", "", false
+		return nil, newSyntaxError(SyntaxErrEmpty, 0)
 	}
+	if !utf8.ValidString(email) {
+		for i := 0; i < len(email); {
+			r, size := utf8.DecodeRuneInString(email[i:])
+			if r == utf8.RuneError && size <= 1 {
+				return nil, newSyntaxError(SyntaxErrInvalidUTF8, i)
+			}
+			i += size
+		}
+	}
+	email = norm.NFC.String(email)
 
 	if len(email) > 254 {
-		log.Detail("SYNTAX", "Email too long: %d chars (max 254)", len(email))
-		return "", "", false
+		return nil, newSyntaxError(SyntaxErrTooLong, 254)
 	}
 
-	// Must contain exactly one @
-	atCount := strings.Count(email, "@")
-	if atCount != 1 {
-		log.Detail("SYNTAX", "Invalid @ count: %d (must be 1)", atCount)
-		return "", "", false
+	addr := &Address{}
+
+	// Local part: quoted-string or dot-string
+	var at int
+	var serr *SyntaxError
+	if email[0] == '"' {
+		if level == SyntaxProvider {
+			return nil, newSyntaxError(SyntaxErrQuotedNotAllowed, 0)
+		}
+		at, serr = parseQuotedLocal(email)
+		addr.Quoted = true
+	} else {
+		at, serr = parseDotString(email, level)
 	}
+	if serr != nil {
+		return nil, serr
+	}
+	addr.LocalPart = email[:at]
 
-	// Split into local and domain parts
-	parts := strings.SplitN(email, "@", 2)
-	localPart = parts[0]
-	domain = parts[1]
+	if len(addr.LocalPart) > 64 {
+		return nil, newSyntaxError(SyntaxErrLocalTooLong, 64)
+	}
 
-	// Validate local part
-	if localPart == "" {
-		log.Detail("SYNTAX", "Empty local part")
-		return "", "", false
+	// Domain: address literal or hostname
+	domainStart := at + 1
+	domain := email[domainStart:]
+	if domain == "" {
+		return nil, newSyntaxError(SyntaxErrDomainEmpty, domainStart)
+	}
+	if i := strings.IndexByte(domain, '@'); i >= 0 {
+		return nil, newSyntaxError(SyntaxErrMultipleAt, domainStart+i)
 	}
 
-	if len(localPart) > 64 {
-		log.Detail("SYNTAX", "Local part too long: %d chars (max 64)", len(localPart))
-		return "", "", false
+	if domain[0] == '[' {
+		if level == SyntaxProvider {
+			return nil, newSyntaxError(SyntaxErrLiteralNotAllowed, domainStart)
+		}
+		ip, serr := parseAddressLiteral(domain, domainStart)
+		if serr != nil {
+			return nil, serr
+		}
+		addr.Domain = domain
+		addr.LiteralIP = ip
+		return addr, nil
 	}
 
-	// Local part cannot start or end with a dot
-	if strings.HasPrefix(localPart, ".") || strings.HasSuffix(localPart, ".") {
-		log.Detail("SYNTAX", "Local part starts/ends with dot")
-		return "", "", false
+	ascii, serr := parseHostname(domain, domainStart, level)
+	if serr != nil {
+		return nil, serr
 	}
+	addr.Domain = ascii
+	return addr, nil
+}
 
-	// No consecutive dots in local part
-	if strings.Contains(localPart, "..") {
-		log.Detail("SYNTAX", "Consecutive dots in local part")
-		return "", "", false
+// parseDotString scans a Dot-string local part and returns the index of the
+// @ separator.
+func parseDotString(email string, level SyntaxLevel) (int, *SyntaxError) {
+	prevDot := false
+	for i, r := range email {
+		switch {
+		case r == '@':
+			if i == 0 {
+				return 0, newSyntaxError(SyntaxErrLocalEmpty, 0)
+			}
+			if prevDot {
+				return 0, newSyntaxError(SyntaxErrLocalDotEnd, i-1)
+			}
+			return i, nil
+		case r == '.':
+			if i == 0 {
+				return 0, newSyntaxError(SyntaxErrLocalDotStart, 0)
+			}
+			if prevDot {
+				return 0, newSyntaxError(SyntaxErrLocalConsecutiveDots, i)
+			}
+			prevDot = true
+			continue
+		case !isAtext(r, level):
+			return 0, newSyntaxError(SyntaxErrLocalInvalidChar, i)
+		}
+		prevDot = false
 	}
+	return 0, newSyntaxError(SyntaxErrMissingAt, len(email))
+}
 
-	// Validate domain
-	if domain == "" {
-		log.Detail("SYNTAX", "Empty domain")
-		return "", "", false
+// parseQuotedLocal scans a Quoted-string local part starting at email[0]
+// and returns the index of the @ separator that follows it.
+func parseQuotedLocal(email string) (int, *SyntaxError) {
+	escaped := false
+	for i, r := range email[1:] {
+		pos := i + 1
+		switch {
+		case escaped:
+			// quoted-pair: backslash followed by any printable ASCII
+			if r < 32 || r > 126 {
+				return 0, newSyntaxError(SyntaxErrQuotedInvalidChar, pos)
+			}
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			end := pos + 1
+			if end == 2 {
+				return 0, newSyntaxError(SyntaxErrLocalEmpty, 0)
+			}
+			if end >= len(email) {
+				return 0, newSyntaxError(SyntaxErrMissingAt, end)
+			}
+			if email[end] != '@' {
+				return 0, newSyntaxError(SyntaxErrLocalInvalidChar, end)
+			}
+			return end, nil
+		case !isQtext(r):
+			return 0, newSyntaxError(SyntaxErrQuotedInvalidChar, pos)
+		}
 	}
+	return 0, newSyntaxError(SyntaxErrQuotedUnterminated, len(email))
+}
 
-	if len(domain) > 253 {
-		log.Detail("SYNTAX", "Domain too long: %d chars (max 253)", len(domain))
-		return "", "", false
+// parseAddressLiteral validates "[IPv4]" or "[IPv6:...]" (RFC 5321 4.1.3)
+func parseAddressLiteral(domain string, offset int) (net.IP, *SyntaxError) {
+	if !strings.HasSuffix(domain, "]") || len(domain) < 3 {
+		return nil, newSyntaxError(SyntaxErrLiteralInvalid, offset+len(domain)-1)
 	}
+	inner := domain[1 : len(domain)-1]
 
-	// Domain must contain at least one dot
-	if !strings.Contains(domain, ".") {
-		log.Detail("SYNTAX", "Domain has no dot")
-		return "", "", false
+	if strings.HasPrefix(inner, "ipv6:") {
+		// Plain IPv4 text is only allowed inside the IPv6v4 forms
+		ip := net.ParseIP(inner[5:])
+		if ip == nil || (ip.To4() != nil && !strings.Contains(inner[5:], ":")) {
+			return nil, newSyntaxError(SyntaxErrLiteralInvalid, offset+6)
+		}
+		return ip, nil
 	}
 
-	// Domain cannot start or end with a dot or hyphen
-	if strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") ||
-		strings.HasPrefix(domain, "-") || strings.HasSuffix(domain, "-") {
-		log.Detail("SYNTAX", "Domain starts/ends with invalid char")
-		return "", "", false
+	ip := net.ParseIP(inner)
+	if ip == nil || ip.To4() == nil || strings.Contains(inner, ":") {
+		return nil, newSyntaxError(SyntaxErrLiteralInvalid, offset+1)
 	}
+	return ip, nil
+}
 
-	// No consecutive dots in domain
-	if strings.Contains(domain, "..") {
-		log.Detail("SYNTAX", "Consecutive dots in domain")
-		return "", "", false
+// parseHostname validates a domain name, converting IDNs to A-labels, and
+// returns the ASCII form.
+func parseHostname(domain string, offset int, level SyntaxLevel) (string, *SyntaxError) {
+	log := debug.GetLogger()
+
+	// Convert internationalized domains to A-labels (xn--...) for DNS.
+	// Positions past this point refer to the start of the domain since
+	// A-label offsets do not map back onto the input.
+	idn := !isASCII(domain)
+	if idn {
+		aLabel, err := idna.Lookup.ToASCII(domain)
+		if err != nil {
+			log.Detail("SYNTAX", "Invalid internationalized domain %s: %v", domain, err)
+			return "", newSyntaxError(SyntaxErrIDNInvalid, offset)
+		}
+		log.Detail("SYNTAX", "IDN domain %s -> %s", domain, aLabel)
+		domain = aLabel
+	}
+	pos := func(i int) int {
+		if idn {
+			return offset
+		}
+		return offset + i
+	}
+
+	if len(domain) > 253 {
+		return "", newSyntaxError(SyntaxErrDomainTooLong, pos(253))
 	}
 
-	// Validate each domain label
+	start := 0
 	labels := strings.Split(domain, ".")
 	for _, label := range labels {
-		if len(label) == 0 || len(label) > 63 {
-			log.Detail("SYNTAX", "Invalid label length: %d", len(label))
-			return "", "", false
+		switch {
+		case label == "":
+			return "", newSyntaxError(SyntaxErrLabelEmpty, pos(start))
+		case len(label) > 63:
+			return "", newSyntaxError(SyntaxErrLabelTooLong, pos(start+63))
+		case label[0] == '-':
+			return "", newSyntaxError(SyntaxErrLabelHyphen, pos(start))
+		case label[len(label)-1] == '-':
+			return "", newSyntaxError(SyntaxErrLabelHyphen, pos(start+len(label)-1))
 		}
-		if strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
-			log.Detail("SYNTAX", "Label starts/ends with hyphen: %s", label)
-			return "", "", false
+		for i := 0; i < len(label); i++ {
+			if !isLetDig(label[i]) && label[i] != '-' {
+				return "", newSyntaxError(SyntaxErrDomainInvalidChar, pos(start+i))
+			}
 		}
+		start += len(label) + 1
+	}
+
+	if level == SyntaxRFC {
+		return domain, nil
+	}
+
+	// Practical and provider levels: public internet domains only
+	if len(labels) < 2 {
+		return "", newSyntaxError(SyntaxErrDomainNoDot, pos(len(domain)))
 	}
 
-	// TLD validation (must be at least 2 chars, only letters)
 	tld := labels[len(labels)-1]
+	tldPos := pos(len(domain) - len(tld))
+	if isAllDigits(tld) {
+		return "", newSyntaxError(SyntaxErrTLDNumeric, tldPos)
+	}
 	if len(tld) < 2 {
-		log.Detail("SYNTAX", "TLD too short: %s", tld)
-		return "", "", false
+		return "", newSyntaxError(SyntaxErrTLDInvalid, tldPos)
+	}
+	if level == SyntaxProvider && !strings.HasPrefix(tld, "xn--") {
+		for i := 0; i < len(tld); i++ {
+			if !(tld[i] >= 'a' && tld[i] <= 'z') {
+				return "", newSyntaxError(SyntaxErrTLDInvalid, tldPos+i)
+			}
+		}
+	}
+
+	return domain, nil
+}
+
+// isAtext reports whether r is allowed in a dot-string atom. RFC 6531 extends
+// atext with any non-ASCII character; the provider level only allows the
+// characters large mailbox providers accept.
+func isAtext(r rune, level SyntaxLevel) bool {
+	if r >= utf8.RuneSelf {
+		return level != SyntaxProvider && !unicode.IsControl(r) && !unicode.IsSpace(r) &&
+			!unicode.Is(unicode.Cf, r)
+	}
+	c := byte(r)
+	if isLetDig(c) {
+		return true
+	}
+	if level == SyntaxProvider {
+		return strings.IndexByte("_-+'", c) >= 0
+	}
+	return strings.IndexByte("!#$%&'*+/=?^_`{|}~-", c) >= 0
+}
+
+// isQtext reports whether r may appear unescaped inside a quoted string
+func isQtext(r rune) bool {
+	if r >= utf8.RuneSelf {
+		return !unicode.IsControl(r)
 	}
+	return r == 32 || r == 33 || (r >= 35 && r <= 91) || (r >= 93 && r <= 126)
+}
+
+func isLetDig(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
 
-	// Check TLD is letters only (no numbers)
-	for _, c := range tld {
-		if !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')) {
-			log.Detail("SYNTAX", "TLD contains non-letter: %s", tld)
-			return "", "", false
+func isAllDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
 		}
 	}
+	return s != ""
+}
 
-	// Final regex check
-	if !emailRegex.MatchString(email) {
-		log.Detail("SYNTAX", "Failed regex validation")
-		return "", "", false
+// RequiresSMTPUTF8 returns true if the address cannot be expressed in ASCII
+// (after A-label conversion of the domain) and therefore needs the SMTPUTF8
+// extension (RFC 6531) to be verified.
+func RequiresSMTPUTF8(localPart string) bool {
+	return !isASCII(localPart)
+}
+
+// ToASCIIAddress converts the domain of email to its A-label form, leaving
+// the local part untouched. It returns email unchanged if conversion fails.
+func ToASCIIAddress(email string) string {
+	i := strings.LastIndex(email, "@")
+	if i < 0 || isASCII(email[i+1:]) {
+		return email
+	}
+	aLabel, err := idna.Lookup.ToASCII(email[i+1:])
+	if err != nil {
+		return email
 	}
+	return email[:i+1] + aLabel
+}
 
-	log.Success("SYNTAX", "Valid syntax - local: %s, domain: %s", localPart, domain)
-	return localPart, domain, true
+// isASCII returns true if s contains only 7-bit ASCII characters
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
 }
 
 // NormalizeEmail normalizes an email address
@@ -139,59 +378,3 @@ func NormalizeEmail(email string) string {
 	email = strings.ToLower(email)
 	return email
 }
-
-// SuggestTypoFix suggests corrections for common domain typos
-func SuggestTypoFix(domain string) string {
-	typoMap := map[string]string{
-		// Gmail typos
-		"gmial.com":   "gmail.com",
-		"gmai.com":    "gmail.com",
-		"gmaill.com":  "gmail.com",
-		"gmail.co":    "gmail.com",
-		"gmail.cm":    "gmail.com",
-		"gamil.com":   "gmail.com",
-		"gnail.com":   "gmail.com",
-		"gmal.com":    "gmail.com",
-		"gmeil.com":   "gmail.com",
-		"g]mail.com":  "gmail.com",
-		"gimail.com":  "gmail.com",
-
-		// Yahoo typos
-		"yaho.com":    "yahoo.com",
-		"yahooo.com":  "yahoo.com",
-		"yhoo.com":    "yahoo.com",
-		"yahoo.co":    "yahoo.com",
-		"yahoo.cm":    "yahoo.com",
-		"yhaoo.com":   "yahoo.com",
-
-		// Hotmail typos
-		"hotmal.com":   "hotmail.com",
-		"hotmial.com":  "hotmail.com",
-		"hotmail.co":   "hotmail.com",
-		"hotmail.cm":   "hotmail.com",
-		"hotmaill.com": "hotmail.com",
-		"homail.com":   "hotmail.com",
-		"htmail.com":   "hotmail.com",
-
-		// Outlook typos
-		"outlok.com":   "outlook.com",
-		"outloo.com":   "outlook.com",
-		"outlook.co":   "outlook.com",
-		"outllook.com": "outlook.com",
-
-		// iCloud typos
-		"iclod.com":  "icloud.com",
-		"icould.com": "icloud.com",
-		"icloud.co":  "icloud.com",
-
-		// Common .com typos
-		"protonmail.co": "protonmail.com",
-		"aol.co":        "aol.com",
-	}
-
-	domain = strings.ToLower(domain)
-	if suggestion, ok := typoMap[domain]; ok {
-		return suggestion
-	}
-	return ""
-}
@@ -0,0 +1,134 @@
+package verifier
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SyntaxErrorCode identifies which syntax rule an address violated
+type SyntaxErrorCode string
+
+const (
+	SyntaxErrEmpty                SyntaxErrorCode = "empty"
+	SyntaxErrTooLong              SyntaxErrorCode = "too_long"
+	SyntaxErrInvalidUTF8          SyntaxErrorCode = "invalid_utf8"
+	SyntaxErrMissingAt            SyntaxErrorCode = "missing_at"
+	SyntaxErrMultipleAt           SyntaxErrorCode = "multiple_at"
+	SyntaxErrLocalEmpty           SyntaxErrorCode = "local_empty"
+	SyntaxErrLocalTooLong         SyntaxErrorCode = "local_too_long"
+	SyntaxErrLocalDotStart        SyntaxErrorCode = "local_dot_start"
+	SyntaxErrLocalDotEnd          SyntaxErrorCode = "local_dot_end"
+	SyntaxErrLocalConsecutiveDots SyntaxErrorCode = "local_consecutive_dots"
+	SyntaxErrLocalInvalidChar     SyntaxErrorCode = "local_invalid_char"
+	SyntaxErrQuotedUnterminated   SyntaxErrorCode = "quoted_unterminated"
+	SyntaxErrQuotedInvalidChar    SyntaxErrorCode = "quoted_invalid_char"
+	SyntaxErrQuotedNotAllowed     SyntaxErrorCode = "quoted_not_allowed"
+	SyntaxErrDomainEmpty          SyntaxErrorCode = "domain_empty"
+	SyntaxErrDomainTooLong        SyntaxErrorCode = "domain_too_long"
+	SyntaxErrDomainNoDot          SyntaxErrorCode = "domain_no_dot"
+	SyntaxErrLabelEmpty           SyntaxErrorCode = "label_empty"
+	SyntaxErrLabelTooLong         SyntaxErrorCode = "label_too_long"
+	SyntaxErrLabelHyphen          SyntaxErrorCode = "label_hyphen"
+	SyntaxErrDomainInvalidChar    SyntaxErrorCode = "domain_invalid_char"
+	SyntaxErrIDNInvalid           SyntaxErrorCode = "idn_invalid"
+	SyntaxErrTLDNumeric           SyntaxErrorCode = "tld_numeric"
+	SyntaxErrTLDInvalid           SyntaxErrorCode = "tld_invalid"
+	SyntaxErrLiteralInvalid       SyntaxErrorCode = "literal_invalid"
+	SyntaxErrLiteralNotAllowed    SyntaxErrorCode = "literal_not_allowed"
+)
+
+// syntaxErrorMessages holds a human-readable description for each code
+var syntaxErrorMessages = map[SyntaxErrorCode]string{
+	SyntaxErrEmpty:                "address is empty",
+	SyntaxErrTooLong:              "address exceeds 254 octets",
+	SyntaxErrInvalidUTF8:          "address is not valid UTF-8",
+	SyntaxErrMissingAt:            "missing @ separator",
+	SyntaxErrMultipleAt:           "unexpected @ in domain",
+	SyntaxErrLocalEmpty:           "local part is empty",
+	SyntaxErrLocalTooLong:         "local part exceeds 64 octets",
+	SyntaxErrLocalDotStart:        "local part starts with a dot",
+	SyntaxErrLocalDotEnd:          "local part ends with a dot",
+	SyntaxErrLocalConsecutiveDots: "local part contains consecutive dots",
+	SyntaxErrLocalInvalidChar:     "invalid character in local part",
+	SyntaxErrQuotedUnterminated:   "quoted local part is not terminated",
+	SyntaxErrQuotedInvalidChar:    "invalid character in quoted local part",
+	SyntaxErrQuotedNotAllowed:     "quoted local parts are not accepted at this strictness",
+	SyntaxErrDomainEmpty:          "domain is empty",
+	SyntaxErrDomainTooLong:        "domain exceeds 253 octets",
+	SyntaxErrDomainNoDot:          "domain has no dot",
+	SyntaxErrLabelEmpty:           "domain contains an empty label",
+	SyntaxErrLabelTooLong:         "domain label exceeds 63 octets",
+	SyntaxErrLabelHyphen:          "domain label starts or ends with a hyphen",
+	SyntaxErrDomainInvalidChar:    "invalid character in domain",
+	SyntaxErrIDNInvalid:           "internationalized domain cannot be converted to IDNA",
+	SyntaxErrTLDNumeric:           "top-level domain is all-numeric",
+	SyntaxErrTLDInvalid:           "top-level domain is not a valid name",
+	SyntaxErrLiteralInvalid:       "invalid address literal",
+	SyntaxErrLiteralNotAllowed:    "address literals are not accepted at this strictness",
+}
+
+// SyntaxError describes why an address failed to parse
+type SyntaxError struct {
+	Code SyntaxErrorCode
+	// Position is the byte offset into the (trimmed) address where the
+	// violation was detected
+	Position int
+}
+
+// Error implements the error interface
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s (position %d)", e.Message(), e.Position)
+}
+
+// Message returns the human-readable description of the error code
+func (e *SyntaxError) Message() string {
+	if msg, ok := syntaxErrorMessages[e.Code]; ok {
+		return msg
+	}
+	return string(e.Code)
+}
+
+func newSyntaxError(code SyntaxErrorCode, pos int) *SyntaxError {
+	return &SyntaxError{Code: code, Position: pos}
+}
+
+// SyntaxLevel controls how strictly addresses are parsed
+type SyntaxLevel int
+
+const (
+	// SyntaxPractical accepts everything RFC 5321 allows except constructs
+	// that never work on the public internet: dotless domains, numeric or
+	// single-character TLDs. This is the default.
+	SyntaxPractical SyntaxLevel = iota
+	// SyntaxRFC follows the RFC 5321 Mailbox grammar (with RFC 6531 UTF-8)
+	SyntaxRFC
+	// SyntaxProvider applies the rules common to large mailbox providers:
+	// dot-atom ASCII local parts only, no quoted strings or address literals
+	SyntaxProvider
+)
+
+// String returns the flag name of the level
+func (l SyntaxLevel) String() string {
+	switch l {
+	case SyntaxRFC:
+		return "rfc"
+	case SyntaxProvider:
+		return "provider"
+	default:
+		return "practical"
+	}
+}
+
+// ParseSyntaxLevel converts a flag value (rfc, practical, provider) to a SyntaxLevel
+func ParseSyntaxLevel(s string) (SyntaxLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "practical":
+		return SyntaxPractical, nil
+	case "rfc", "strict":
+		return SyntaxRFC, nil
+	case "provider":
+		return SyntaxProvider, nil
+	default:
+		return SyntaxPractical, fmt.Errorf("unknown syntax level %q (use rfc, practical or provider)", s)
+	}
+}
@@ -0,0 +1,66 @@
+package verifier
+
+import "testing"
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		email  string
+		level  SyntaxLevel
+		domain string          // expected domain when valid
+		code   SyntaxErrorCode // expected error code when invalid
+		pos    int
+	}{
+		{email: "user@example.com", level: SyntaxPractical, domain: "example.com"},
+		{email: "first.last+tag@sub.example.co.uk", level: SyntaxPractical, domain: "sub.example.co.uk"},
+		{email: "user@bücher.de", level: SyntaxPractical, domain: "xn--bcher-kva.de"},
+		{email: "用户@example.com", level: SyntaxPractical, domain: "example.com"},
+		{email: `"john doe"@example.com`, level: SyntaxPractical, domain: "example.com"},
+		{email: "user@[192.0.2.1]", level: SyntaxPractical, domain: "[192.0.2.1]"},
+		{email: "user@[ipv6:2001:db8::1]", level: SyntaxPractical, domain: "[ipv6:2001:db8::1]"},
+		{email: "user@[ipv6:::ffff:192.0.2.1]", level: SyntaxRFC, domain: "[ipv6:::ffff:192.0.2.1]"},
+		{email: "user@localhost", level: SyntaxRFC, domain: "localhost"},
+		{email: "o'brien@example.com", level: SyntaxProvider, domain: "example.com"},
+
+		{email: "", level: SyntaxPractical, code: SyntaxErrEmpty, pos: 0},
+		{email: "userexample.com", level: SyntaxPractical, code: SyntaxErrMissingAt, pos: 15},
+		{email: "user@exa@mple.com", level: SyntaxPractical, code: SyntaxErrMultipleAt, pos: 8},
+		{email: "@example.com", level: SyntaxPractical, code: SyntaxErrLocalEmpty, pos: 0},
+		{email: ".user@example.com", level: SyntaxPractical, code: SyntaxErrLocalDotStart, pos: 0},
+		{email: "user.@example.com", level: SyntaxPractical, code: SyntaxErrLocalDotEnd, pos: 4},
+		{email: "us..er@example.com", level: SyntaxPractical, code: SyntaxErrLocalConsecutiveDots, pos: 3},
+		{email: "us er@example.com", level: SyntaxPractical, code: SyntaxErrLocalInvalidChar, pos: 2},
+		{email: `"unterminated@example.com`, level: SyntaxPractical, code: SyntaxErrQuotedUnterminated, pos: 25},
+		{email: `"john doe"@example.com`, level: SyntaxProvider, code: SyntaxErrQuotedNotAllowed, pos: 0},
+		{email: "user!x@example.com", level: SyntaxProvider, code: SyntaxErrLocalInvalidChar, pos: 4},
+		{email: "user@", level: SyntaxPractical, code: SyntaxErrDomainEmpty, pos: 5},
+		{email: "user@localhost", level: SyntaxPractical, code: SyntaxErrDomainNoDot, pos: 14},
+		{email: "user@example..com", level: SyntaxPractical, code: SyntaxErrLabelEmpty, pos: 13},
+		{email: "user@-example.com", level: SyntaxPractical, code: SyntaxErrLabelHyphen, pos: 5},
+		{email: "user@exa_mple.com", level: SyntaxPractical, code: SyntaxErrDomainInvalidChar, pos: 8},
+		{email: "user@example.123", level: SyntaxPractical, code: SyntaxErrTLDNumeric, pos: 13},
+		{email: "user@example.c", level: SyntaxPractical, code: SyntaxErrTLDInvalid, pos: 13},
+		{email: "user@[192.0.2.1]", level: SyntaxProvider, code: SyntaxErrLiteralNotAllowed, pos: 5},
+		{email: "user@[ipv6:1.2.3.4]", level: SyntaxRFC, code: SyntaxErrLiteralInvalid, pos: 11},
+		{email: "user@[::1]", level: SyntaxRFC, code: SyntaxErrLiteralInvalid, pos: 6},
+		{email: "user@[300.0.0.1]", level: SyntaxRFC, code: SyntaxErrLiteralInvalid, pos: 6},
+	}
+
+	for _, tt := range tests {
+		addr, serr := parseAddress(tt.email, tt.level)
+		if tt.code == "" {
+			if serr != nil {
+				t.Errorf("%q (%s): unexpected error %v", tt.email, tt.level, serr)
+			} else if addr.Domain != tt.domain {
+				t.Errorf("%q (%s): domain = %q, want %q", tt.email, tt.level, addr.Domain, tt.domain)
+			}
+			continue
+		}
+		if serr == nil {
+			t.Errorf("%q (%s): accepted, want %s", tt.email, tt.level, tt.code)
+			continue
+		}
+		if serr.Code != tt.code || serr.Position != tt.pos {
+			t.Errorf("%q (%s): got %s at %d, want %s at %d", tt.email, tt.level, serr.Code, serr.Position, tt.code, tt.pos)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package verifier
+
+import (
+	"strings"
+)
+
+// popularDomains is the corpus typo suggestions are drawn from. Domains in
+// this list are never "corrected", so close-but-legitimate neighbours
+// (mail.com vs gmail.com, live.ca vs live.com) belong here too.
+var popularDomains = []string{
+	// Google
+	"gmail.com", "googlemail.com",
+	// Microsoft
+	"outlook.com", "hotmail.com", "hotmail.co.uk", "hotmail.fr", "hotmail.de",
+	"hotmail.it", "hotmail.es", "live.com", "live.co.uk", "live.fr", "live.de",
+	"live.ca", "msn.com",
+	// Yahoo / AOL
+	"yahoo.com", "yahoo.co.uk", "yahoo.fr", "yahoo.de", "yahoo.it", "yahoo.es",
+	"yahoo.ca", "yahoo.co.in", "yahoo.com.au", "yahoo.com.br", "ymail.com",
+	"rocketmail.com", "aol.com",
+	// Apple
+	"icloud.com", "me.com", "mac.com",
+	// Privacy-focused
+	"protonmail.com", "proton.me", "pm.me", "tutanota.com", "fastmail.com",
+	// ISPs (US)
+	"comcast.net", "verizon.net", "att.net", "sbcglobal.net", "cox.net",
+	"charter.net", "bellsouth.net", "earthlink.net",
+	// Europe / Asia
+	"gmx.com", "gmx.de", "gmx.net", "web.de", "t-online.de", "orange.fr",
+	"free.fr", "laposte.net", "libero.it", "virgilio.it", "btinternet.com",
+	"yandex.ru", "yandex.com", "mail.ru", "qq.com", "163.com", "126.com",
+	"naver.com",
+	// Others
+	"mail.com", "zoho.com", "email.com", "inbox.com",
+}
+
+// tldTypos maps mistyped top-level domains to the intended one. Only
+// strings that are not themselves delegated TLDs are listed.
+var tldTypos = map[string]string{
+	"con":  "com",
+	"cmo":  "com",
+	"cpm":  "com",
+	"ocm":  "com",
+	"vom":  "com",
+	"xom":  "com",
+	"cim":  "com",
+	"comm": "com",
+	"coom": "com",
+	"comn": "com",
+	"nte":  "net",
+	"nett": "net",
+	"ogr":  "org",
+	"rog":  "org",
+	"orgg": "org",
+}
+
+// qwertyRows is used to derive which keys are physically adjacent
+var qwertyRows = []string{
+	"1234567890-",
+	"qwertyuiop",
+	"asdfghjkl",
+	"zxcvbnm",
+}
+
+// keyboardNeighbors maps each key to the keys next to it (same row and the
+// rows above/below at the same or neighbouring column)
+var keyboardNeighbors = buildKeyboardNeighbors()
+
+func buildKeyboardNeighbors() map[byte]string {
+	neighbors := make(map[byte]string)
+	for r, row := range qwertyRows {
+		for c := 0; c < len(row); c++ {
+			var adj []byte
+			for dr := -1; dr <= 1; dr++ {
+				if r+dr < 0 || r+dr >= len(qwertyRows) {
+					continue
+				}
+				other := qwertyRows[r+dr]
+				for dc := -1; dc <= 1; dc++ {
+					if (dr == 0 && dc == 0) || c+dc < 0 || c+dc >= len(other) {
+						continue
+					}
+					adj = append(adj, other[c+dc])
+				}
+			}
+			neighbors[row[c]] = string(adj)
+		}
+	}
+	return neighbors
+}
+
+// substitutionCost is cheaper for keys next to each other, since a slipped
+// finger is the most common source of single-character typos
+func substitutionCost(a, b byte) float64 {
+	if a == b {
+		return 0
+	}
+	if strings.IndexByte(keyboardNeighbors[a], b) >= 0 {
+		return 0.5
+	}
+	return 1
+}
+
+// typoDistance returns the optimal-string-alignment Damerau-Levenshtein
+// distance between a and b, with keyboard-adjacent substitutions weighted
+// at half cost.
+func typoDistance(a, b string) float64 {
+	la, lb := len(a), len(b)
+	d := make([][]float64, la+1)
+	for i := range d {
+		d[i] = make([]float64, lb+1)
+		d[i][0] = float64(i)
+	}
+	for j := 0; j <= lb; j++ {
+		d[0][j] = float64(j)
+	}
+
+	for i := 1; i <= la; i++ {
+		for j := 1; j <= lb; j++ {
+			best := d[i-1][j] + 1 // deletion
+			if v := d[i][j-1] + 1; v < best {
+				best = v // insertion
+			}
+			if v := d[i-1][j-1] + substitutionCost(a[i-1], b[j-1]); v < best {
+				best = v // substitution
+			}
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				if v := d[i-2][j-2] + 1; v < best {
+					best = v // transposition
+				}
+			}
+			d[i][j] = best
+		}
+	}
+	return d[la][lb]
+}
+
+// maxTypoDistance is the largest distance still considered a typo of
+// candidate. Short domains get less slack to avoid false positives: up to 6
+// characters only a slipped key counts (aon.com is not aol.com), besides
+// two swapped letters, which isTransposition accepts.
+func maxTypoDistance(candidate string) float64 {
+	switch {
+	case len(candidate) >= 10:
+		return 2
+	case len(candidate) > 6:
+		return 1
+	}
+	return 0.5
+}
+
+// isTransposition reports whether a and b differ only by two neighbouring
+// characters swapped
+func isTransposition(a, b string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := 0; i+1 < len(a); i++ {
+		if a[i] != b[i] {
+			return a[i] == b[i+1] && a[i+1] == b[i] && a[i+2:] == b[i+2:]
+		}
+	}
+	return false
+}
+
+// SuggestTypoFix suggests a correction for a likely mistyped domain, or
+// returns "" when the domain looks intentional. The domain is compared
+// against a corpus of popular mail domains using keyboard-weighted
+// Damerau-Levenshtein distance; failing that, well-known TLD typos
+// (.con, .cmo, ...) are corrected.
+func SuggestTypoFix(domain string) string {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	if domain == "" {
+		return ""
+	}
+
+	best := ""
+	bestDist := 0.0
+	for _, candidate := range popularDomains {
+		if candidate == domain {
+			return ""
+		}
+		dist := typoDistance(domain, candidate)
+		if dist > maxTypoDistance(candidate) && !isTransposition(domain, candidate) {
+			continue
+		}
+		if best == "" || dist < bestDist {
+			best, bestDist = candidate, dist
+		}
+	}
+	if best != "" {
+		return best
+	}
+
+	// TLD typo on an otherwise unknown domain
+	if i := strings.LastIndex(domain, "."); i > 0 {
+		if fix, ok := tldTypos[domain[i+1:]]; ok {
+			return domain[:i+1] + fix
+		}
+	}
+
+	return ""
+}
@@ -2,6 +2,8 @@ package verifier
 
 import (
 	"fmt"
+	"net"
+	"strings"
 	"time"
 
 	"github.com/nephila016/emailchecker/internal/classifier"
@@ -18,14 +20,40 @@ type Config struct {
 	HELODomain  string
 
 	// Verification options
+	SyntaxLevel   SyntaxLevel
 	SkipSMTP      bool
 	CheckCatchAll bool
 	SkipTLSVerify bool
+	// CatchAllProbes is how many random recipients are probed per domain
+	// (0 = DefaultCatchAllProbes)
+	CatchAllProbes int
+	// CatchAllDataProbe issues DATA for an accepted random recipient to
+	// detect accept-then-bounce servers (the connection is dropped before
+	// any message is sent)
+	CatchAllDataProbe bool
+	// CatchAllCacheTTL is how long catch-all verdicts are reused for other
+	// addresses at the same domain or MX host (0 = DefaultCatchAllCacheTTL,
+	// negative = never cache)
+	CatchAllCacheTTL time.Duration
 
 	// Classification options
 	CheckDisposable   bool
 	CheckRole         bool
 	CheckFreeProvider bool
+	// RoleConfidence is the minimum confidence at which a local part is
+	// reported as a role account (zero means medium)
+	RoleConfidence classifier.RoleConfidence
+	// ConfirmTypoMX only keeps a "did you mean" suggestion if the suggested
+	// domain has MX records
+	ConfirmTypoMX bool
+	// CheckProviderRules rejects local parts that the detected mailbox
+	// provider (Gmail, Outlook, Yahoo, ...) would never issue
+	CheckProviderRules bool
+	// Scoring is the model used for ConfidenceScore (nil = default profile)
+	Scoring *ScoringModel
+
+	// Classifiers add custom tags to the result (see classifier.Registered)
+	Classifiers []classifier.Classifier
 
 	// Retry settings
 	// MaxMXFallback is how many MX servers to try before giving up (0 = try all)
@@ -35,22 +63,24 @@ type Config struct {
 // DefaultConfig returns default verifier configuration
 func DefaultConfig() *Config {
 	return &Config{
-		Port:              25,
-		Timeout:           15 * time.Second,
-		FromAddress:       "test@gmail.com",
-		HELODomain:        "mail.verification-check.com",
-		CheckCatchAll:     false,
-		SkipTLSVerify:     true, // Many servers use self-signed certs; allow override
-		CheckDisposable:   true,
-		CheckRole:         true,
-		CheckFreeProvider: true,
-		MaxMXFallback:     3, // Try up to 3 MX servers before giving up
+		Port:               25,
+		Timeout:            15 * time.Second,
+		FromAddress:        "test@gmail.com",
+		HELODomain:         "mail.verification-check.com",
+		CheckCatchAll:      false,
+		SkipTLSVerify:      true, // Many servers use self-signed certs; allow override
+		CheckDisposable:    true,
+		CheckRole:          true,
+		CheckFreeProvider:  true,
+		CheckProviderRules: true,
+		MaxMXFallback:      3, // Try up to 3 MX servers before giving up
 	}
 }
 
 // Verifier performs email verification
 type Verifier struct {
-	config *Config
+	config   *Config
+	catchAll catchAllCache
 }
 
 // New creates a new Verifier
@@ -68,55 +98,90 @@ func (v *Verifier) Verify(email string) *Result {
 
 	totalTimer := log.StartTimer("VERIFY", fmt.Sprintf("Full verification for %s", email))
 	defer func() {
+		result.ApplyScore(v.config.Scoring)
 		result.LatencyMs = totalTimer.Elapsed().Milliseconds()
 		totalTimer.Stop()
 	}()
 
 	// Layer 1: Syntax validation
 	log.Info("VERIFY", "Layer 1: Syntax validation")
-	localPart, domain, valid := ValidateSyntax(email)
-	result.SyntaxValid = valid
-	result.LocalPart = localPart
-	result.Domain = domain
-
-	if !valid {
-		result.SetInvalid(0, "", "Invalid email syntax")
+	addr, err := ParseAddress(email, v.config.SyntaxLevel)
+	if err != nil {
+		result.SyntaxValid = false
+		if serr, ok := err.(*SyntaxError); ok {
+			result.SyntaxError = string(serr.Code)
+		}
+		result.SetInvalid(0, "", "Invalid email syntax: "+err.Error())
 		return result
 	}
 
-	// Optional: hint about likely typos
-	if suggestion := SuggestTypoFix(domain); suggestion != "" {
-		log.Info("VERIFY", "Possible typo detected: %s -> %s", domain, suggestion)
+	localPart, domain := addr.LocalPart, addr.Domain
+	result.SyntaxValid = true
+	result.LocalPart = localPart
+	result.Domain = domain
+	result.SMTPUTF8 = RequiresSMTPUTF8(localPart)
+	result.LocalPartScore = classifier.ScoreLocalPart(localPart)
+	result.Generated = result.LocalPartScore < classifier.GeneratedThreshold
+	if result.Generated {
+		log.Info("VERIFY", "Local part looks machine-generated (score %d)", result.LocalPartScore)
 	}
 
 	// Layer 2: Domain / MX lookup
 	log.Info("VERIFY", "Layer 2: Domain/MX validation")
-	dnsResult, err := LookupMX(domain, v.config.Timeout)
-	if err != nil {
-		result.SetInvalid(0, "", fmt.Sprintf("Domain error: %v", err))
-		return result
+	if addr.LiteralIP != nil {
+		// Address literals name the mail server directly; there is no MX
+		log.Detail("VERIFY", "Address literal, skipping MX lookup: %s", addr.LiteralIP)
+		result.MXRecords = []string{addr.LiteralIP.String()}
+		result.MXHost = result.MXRecords[0]
+	} else {
+		dnsResult, err := LookupMX(domain, v.config.Timeout)
+		if err != nil {
+			result.CanonicalEmail = CanonicalizeEmail(email)
+			v.hintTypo(result, email)
+			result.SetInvalid(0, "", fmt.Sprintf("Domain error: %v", err))
+			return result
+		}
+
+		result.HasMX = dnsResult.HasMX
+		result.MXRecords = dnsResult.GetMXHosts()
+		if len(result.MXRecords) > 0 {
+			result.MXHost = result.MXRecords[0]
+		}
+		v.hintTypo(result, email)
 	}
 
-	result.HasMX = dnsResult.HasMX
-	result.MXRecords = dnsResult.GetMXHosts()
+	// MX data lets Workspace/M365-hosted domains get provider rules
 	if len(result.MXRecords) > 0 {
-		result.MXHost = result.MXRecords[0]
+		result.CanonicalEmail = CanonicalizeEmailMX(email, result.MXRecords)
+	} else {
+		result.CanonicalEmail = CanonicalizeEmail(email)
 	}
 
 	// Layer 3: Pre-SMTP classification
 	log.Info("VERIFY", "Layer 3: Pre-SMTP classification")
 
 	if v.config.CheckDisposable {
-		result.Disposable = classifier.IsDisposable(domain)
-		if result.Disposable {
-			log.Info("CLASSIFY", "Disposable email detected: %s", domain)
+		if match := v.matchDisposable(domain, result.MXRecords); match != nil {
+			result.Disposable = true
+			result.DisposableReason = match.String()
+			result.DisposableVia = match.Via
+			log.Info("CLASSIFY", "Disposable email detected: %s (%s)", domain, result.DisposableReason)
 		}
 	}
 
 	if v.config.CheckRole {
-		result.RoleAccount = classifier.IsRoleAccount(localPart)
+		minConfidence := v.config.RoleConfidence
+		if minConfidence == classifier.RoleConfidenceNone {
+			minConfidence = classifier.RoleConfidenceMedium
+		}
+		role := classifier.DetectRole(localPart)
+		result.RoleAccount = role.Confidence >= minConfidence
 		if result.RoleAccount {
-			log.Info("CLASSIFY", "Role account detected: %s", localPart)
+			result.RoleCategory = string(role.Category)
+			result.RoleConfidence = role.Confidence.String()
+			log.Info("CLASSIFY", "Role account detected: %s (%s, %s confidence)", localPart, role.Category, role.Confidence)
+		} else if role.Confidence != classifier.RoleConfidenceNone {
+			log.Detail("CLASSIFY", "Role term %q below threshold (%s confidence)", role.Term, role.Confidence)
 		}
 	}
 
@@ -127,37 +192,200 @@ func (v *Verifier) Verify(email string) *Result {
 		}
 	}
 
+	result.MailboxProvider = string(classifier.DetectProvider(domain, result.MXRecords))
+	if result.MailboxProvider != "" {
+		log.Detail("CLASSIFY", "Mailbox provider: %s", result.MailboxProvider)
+	}
+
+	if len(v.config.Classifiers) > 0 {
+		result.Tags = classifier.RunClassifiers(v.config.Classifiers, &classifier.Input{
+			LocalPart: localPart,
+			Domain:    domain,
+			MXHosts:   result.MXRecords,
+		})
+		if len(result.Tags) > 0 {
+			log.Info("CLASSIFY", "Tags: %s", strings.Join(result.Tags, ", "))
+		}
+	}
+
+	if v.config.CheckProviderRules {
+		provider := classifier.Provider(result.MailboxProvider)
+		if ok, reason := classifier.ValidateLocalPart(provider, localPart); !ok {
+			log.Info("CLASSIFY", "Local part rejected by %s rules: %s", provider, reason)
+			result.SetInvalid(0, "", reason)
+			result.SubStatus = SubStatusProviderRule
+			return result
+		}
+	}
+
 	// Skip SMTP if configured
 	if v.config.SkipSMTP {
 		log.Info("VERIFY", "SMTP verification skipped (--skip-smtp)")
 		result.SetUnknown("SMTP verification skipped")
-		result.ConfidenceScore = calculateConfidence(result)
 		return result
 	}
 
 	// Layer 4: SMTP verification
 	log.Info("VERIFY", "Layer 4: SMTP verification")
 
+	// IDN domains are probed using their A-label form
+	smtpAddress := ToASCIIAddress(email)
+
+	// With a verdict cache, catch-all is probed on a connection of its own
+	// once an address is accepted, so that concurrent workers at the same
+	// domain wait for that probe only. Without one, every address is probed
+	// within its own transaction.
+	probeInline := v.config.CheckCatchAll && v.config.CatchAllCacheTTL < 0
+
 	// Use custom host if provided, otherwise walk MX records in priority order
 	if v.config.CustomHost != "" {
-		smtpResult, smtpErr := v.trySMTP(v.config.CustomHost, email)
+		smtpResult, smtpErr := v.trySMTP(v.config.CustomHost, smtpAddress, probeInline)
 		v.copySmtpResult(result, smtpResult, smtpErr)
 	} else {
 		if len(result.MXRecords) == 0 {
 			result.SetInvalid(0, "", "No mail server found")
 			return result
 		}
-		v.tryMXFallback(result, email)
+		v.tryMXFallback(result, smtpAddress, probeInline)
+	}
+
+	switch {
+	case probeInline:
+		if result.catchAllVerdict != nil && result.catchAllVerdict.conclusive() {
+			v.catchAll.store(domain, v.primaryHost(result), result.MailboxProvider, result.catchAllVerdict, v.config.CatchAllCacheTTL)
+		}
+	case v.config.CheckCatchAll:
+		v.applyDomainCatchAll(result, domain, emailDomain(smtpAddress))
 	}
 
-	// Final confidence score
-	result.ConfidenceScore = calculateConfidence(result)
 	return result
 }
 
+// applyDomainCatchAll records the catch-all verdict for domain on result.
+// An accepted address gets a verdict, probing the host that accepted it if
+// none is cached; other addresses only reuse a cached one.
+func (v *Verifier) applyDomainCatchAll(result *Result, domain, asciiDomain string) {
+	log := debug.GetLogger()
+	host := v.primaryHost(result)
+
+	switch result.Status {
+	case StatusError:
+		return
+	case StatusValid:
+	default:
+		if verdict, ok := v.catchAll.lookup(domain, host, result.MailboxProvider); ok {
+			result.applyCatchAll(verdict)
+		}
+		return
+	}
+
+	verdict, release := v.catchAll.acquire(domain, host, result.MailboxProvider)
+	if release == nil {
+		log.Detail("CATCHALL", "Using cached verdict for %s", domain)
+		result.applyCatchAll(verdict)
+		return
+	}
+	defer release()
+
+	real := RcptReply{Code: result.StatusCode, Response: result.SMTPResponse}
+	verdict, err := probeCatchAll(v.smtpConfig(host), asciiDomain, real)
+	if err != nil {
+		log.Detail("CATCHALL", "Catch-all probe for %s failed: %v", domain, err)
+		return
+	}
+	if verdict.conclusive() {
+		v.catchAll.store(domain, host, result.MailboxProvider, verdict, v.config.CatchAllCacheTTL)
+	}
+	result.applyCatchAll(verdict)
+}
+
+// primaryHost returns the SMTP host addresses at domain are checked against
+func (v *Verifier) primaryHost(result *Result) string {
+	if v.config.CustomHost != "" {
+		return v.config.CustomHost
+	}
+	return result.MXHost
+}
+
+// CheckCatchAll probes whether domain accepts arbitrary recipients, using
+// the same settings, MX fallback and verdict cache as Verify
+func (v *Verifier) CheckCatchAll(domain string, mxHosts []string) (*CatchAllVerdict, error) {
+	hosts := mxHosts
+	if v.config.CustomHost != "" {
+		hosts = []string{v.config.CustomHost}
+	}
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("no mail server found for %s", domain)
+	}
+	provider := string(classifier.DetectProvider(domain, mxHosts))
+
+	if v.config.CatchAllCacheTTL >= 0 {
+		verdict, release := v.catchAll.acquire(domain, hosts[0], provider)
+		if release == nil {
+			debug.GetLogger().Detail("CATCHALL", "Using cached verdict for %s", domain)
+			return verdict, nil
+		}
+		defer release()
+	}
+
+	limit := len(hosts)
+	if v.config.MaxMXFallback > 0 && v.config.MaxMXFallback < limit {
+		limit = v.config.MaxMXFallback
+	}
+
+	var lastErr error
+	for _, host := range hosts[:limit] {
+		verdict, err := ProbeCatchAll(v.smtpConfig(host), emailDomain(ToASCIIAddress("@"+domain)))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if verdict.conclusive() {
+			v.catchAll.store(domain, host, provider, verdict, v.config.CatchAllCacheTTL)
+		}
+		return verdict, nil
+	}
+	return nil, lastErr
+}
+
+// CatchAllStats reports how many domains were probed for catch-all and how
+// many addresses reused a cached verdict
+func (v *Verifier) CatchAllStats() CatchAllCacheStats {
+	return v.catchAll.stats()
+}
+
+// hintTypo sets DidYouMean when the domain looks mistyped. A domain with
+// MX records of its own receives mail and is taken as intended.
+func (v *Verifier) hintTypo(result *Result, email string) {
+	if result.HasMX {
+		return
+	}
+	if suggestion := v.suggestDomain(result.Domain); suggestion != "" {
+		debug.GetLogger().Info("VERIFY", "Possible typo detected: %s -> %s", result.Domain, suggestion)
+		trimmed := strings.TrimSpace(email)
+		result.DidYouMean = trimmed[:strings.LastIndex(trimmed, "@")+1] + suggestion
+	}
+}
+
+// suggestDomain returns a typo correction for domain, optionally requiring
+// that the suggested domain actually receives mail
+func (v *Verifier) suggestDomain(domain string) string {
+	suggestion := SuggestTypoFix(domain)
+	if suggestion == "" || !v.config.ConfirmTypoMX {
+		return suggestion
+	}
+
+	dnsResult, err := LookupMX(suggestion, v.config.Timeout)
+	if err != nil || !dnsResult.HasMX {
+		debug.GetLogger().Detail("VERIFY", "Discarding typo suggestion %s: no MX", suggestion)
+		return ""
+	}
+	return suggestion
+}
+
 // tryMXFallback attempts SMTP verification against MX records in priority order.
 // It stops at the first non-error result or when MaxMXFallback is reached.
-func (v *Verifier) tryMXFallback(result *Result, email string) {
+func (v *Verifier) tryMXFallback(result *Result, email string, checkCatchAll bool) {
 	log := debug.GetLogger()
 
 	limit := len(result.MXRecords)
@@ -172,8 +400,9 @@ func (v *Verifier) tryMXFallback(result *Result, email string) {
 			log.Info("VERIFY", "Primary MX failed, trying fallback MX[%d]: %s", i, mxHost)
 		}
 
-		smtpResult, err := v.trySMTP(mxHost, email)
+		smtpResult, err := v.trySMTP(mxHost, email, checkCatchAll)
 		v.copySmtpResult(result, smtpResult, err)
+		result.MXHost = mxHost
 
 		// Stop if we got a definitive answer (not a connection/transport error)
 		if result.Status != StatusError {
@@ -186,16 +415,22 @@ func (v *Verifier) tryMXFallback(result *Result, email string) {
 }
 
 // trySMTP performs SMTP verification against a single host
-func (v *Verifier) trySMTP(host, email string) (*Result, error) {
-	smtpConfig := &SMTPConfig{
-		Host:          host,
-		Port:          v.config.Port,
-		Timeout:       v.config.Timeout,
-		FromAddress:   v.config.FromAddress,
-		HELODomain:    v.config.HELODomain,
-		SkipTLSVerify: v.config.SkipTLSVerify,
-	}
-	return VerifyEmail(smtpConfig, email, v.config.CheckCatchAll)
+func (v *Verifier) trySMTP(host, email string, checkCatchAll bool) (*Result, error) {
+	return VerifyEmail(v.smtpConfig(host), email, checkCatchAll)
+}
+
+// smtpConfig builds the SMTP settings for host from the verifier config
+func (v *Verifier) smtpConfig(host string) *SMTPConfig {
+	return &SMTPConfig{
+		Host:              host,
+		Port:              v.config.Port,
+		Timeout:           v.config.Timeout,
+		FromAddress:       v.config.FromAddress,
+		HELODomain:        v.config.HELODomain,
+		SkipTLSVerify:     v.config.SkipTLSVerify,
+		CatchAllProbes:    v.config.CatchAllProbes,
+		CatchAllDataProbe: v.config.CatchAllDataProbe,
+	}
 }
 
 // copySmtpResult copies SMTP result fields into the main result
@@ -208,11 +443,15 @@ func (v *Verifier) copySmtpResult(result *Result, smtpResult *Result, err error)
 	}
 	result.Valid = smtpResult.Valid
 	result.Status = smtpResult.Status
+	result.SubStatus = smtpResult.SubStatus
 	result.StatusCode = smtpResult.StatusCode
 	result.SMTPResponse = smtpResult.SMTPResponse
 	result.Reason = smtpResult.Reason
 	result.CatchAll = smtpResult.CatchAll
 	result.CatchAllChecked = smtpResult.CatchAllChecked
+	result.CatchAllProbability = smtpResult.CatchAllProbability
+	result.AcceptThenBounce = smtpResult.AcceptThenBounce
+	result.catchAllVerdict = smtpResult.catchAllVerdict
 	result.TLSUsed = smtpResult.TLSUsed
 	result.SMTPSuccess = smtpResult.SMTPSuccess
 	if smtpResult.Error != "" {
@@ -240,6 +479,26 @@ func (v *Verifier) QuickCheck(email string) *Result {
 	return quickV.Verify(email)
 }
 
+// matchDisposable checks domain against the disposable lists and, failing
+// that, its MX hosts against known disposable mail infrastructure. MX hosts
+// are only resolved to IPs when disposable netblocks are configured.
+func (v *Verifier) matchDisposable(domain string, mxHosts []string) *classifier.DisposableMatch {
+	if match := classifier.MatchDisposable(domain); match != nil {
+		return match
+	}
+
+	var ips []net.IP
+	if len(mxHosts) > 0 && classifier.HasMXNetblocks() {
+		addrs, _ := ResolveMXToIP(mxHosts[0], v.config.Timeout)
+		for _, a := range addrs {
+			if ip := net.ParseIP(a); ip != nil {
+				ips = append(ips, ip)
+			}
+		}
+	}
+	return classifier.MatchDisposableMX(mxHosts, ips)
+}
+
 // CheckDomain checks domain-level information (MX, SPF, DMARC, classification)
 func (v *Verifier) CheckDomain(domain string) (*DomainResult, error) {
 	log := debug.GetLogger()
@@ -264,23 +523,32 @@ func (v *Verifier) CheckDomain(domain string) (*DomainResult, error) {
 	log.Info("DOMAIN", "Checking DMARC record")
 	result.DMARCRecord, result.HasDMARC = LookupDMARC(domain, v.config.Timeout)
 
-	result.IsDisposable = classifier.IsDisposable(domain)
+	if match := v.matchDisposable(domain, result.MXRecords); match != nil {
+		result.IsDisposable = true
+		result.DisposableReason = match.String()
+		result.DisposableVia = match.Via
+	}
 	result.IsFreeProvider = classifier.IsFreeProvider(domain)
+	result.MailboxProvider = string(classifier.DetectProvider(domain, result.MXRecords))
 
 	return result, nil
 }
 
 // DomainResult contains domain-level check results
 type DomainResult struct {
-	Domain         string   `json:"domain"`
-	HasMX          bool     `json:"has_mx"`
-	MXRecords      []string `json:"mx_records"`
-	HasSPF         bool     `json:"has_spf"`
-	SPFRecord      string   `json:"spf_record,omitempty"`
-	HasDMARC       bool     `json:"has_dmarc"`
-	DMARCRecord    string   `json:"dmarc_record,omitempty"`
-	IsCatchAll     bool     `json:"is_catch_all"`
-	IsDisposable   bool     `json:"is_disposable"`
-	IsFreeProvider bool     `json:"is_free_provider"`
-	Error          string   `json:"error,omitempty"`
+	Domain           string           `json:"domain"`
+	HasMX            bool             `json:"has_mx"`
+	MXRecords        []string         `json:"mx_records"`
+	HasSPF           bool             `json:"has_spf"`
+	SPFRecord        string           `json:"spf_record,omitempty"`
+	HasDMARC         bool             `json:"has_dmarc"`
+	DMARCRecord      string           `json:"dmarc_record,omitempty"`
+	IsCatchAll       bool             `json:"is_catch_all"`
+	CatchAll         *CatchAllVerdict `json:"catch_all,omitempty"`
+	IsDisposable     bool             `json:"is_disposable"`
+	DisposableReason string           `json:"disposable_reason,omitempty"`
+	DisposableVia    string           `json:"disposable_via,omitempty"`
+	IsFreeProvider   bool             `json:"is_free_provider"`
+	MailboxProvider  string           `json:"mailbox_provider,omitempty"`
+	Error            string           `json:"error,omitempty"`
 }
@@ -200,6 +200,7 @@ func (p *Pool) worker(id int) {
 
 			// Verify the email
 			result := p.verifier.Verify(job.Email)
+			result.Index = job.Index
 
 			atomic.AddInt64(&p.processed, 1)
 			if result.Status == verifier.StatusError {